@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
@@ -224,6 +225,14 @@ func startServer(s *http.Server, ln net.Listener) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "sign" {
+		if err := signCommand(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	configPath := flag.String("config", "/etc/mort/mort.yml", "Path to configuration")
 	versionCmd := flag.Bool("version", false, "get mort version")
 	flag.Parse()
@@ -286,7 +295,11 @@ func main() {
 			obj, err := object.NewFileObject(req.URL, imgConfig)
 			if err != nil {
 				monitoring.Logs().Errorf("Unable to create file object err = %s", err)
-				response.NewError(400, err).SetDebug(&object.FileObject{Debug: debug}).Send(resWriter)
+				status := 400
+				if errors.Is(err, object.ErrInvalidSignature) {
+					status = 403
+				}
+				response.NewError(status, err).SetDebug(&object.FileObject{Debug: debug}).Send(resWriter)
 				return
 			}
 			obj.Debug = debug
@@ -301,7 +314,7 @@ func main() {
 
 			// FIXME
 			res.Set("Access-Control-Allow-Headers", "Content-Type, X-Amz-Public-Width, X-Amz-Public-Height")
-			res.Set("Access-Control-Expose-Headers", "Content-Type, X-Amz-Public-Width, X-Amz-Public-Height")
+			res.Set("Access-Control-Expose-Headers", "Content-Type, X-Amz-Public-Width, X-Amz-Public-Height, X-Amz-Meta-Public-Width, X-Amz-Meta-Public-Height, X-Amz-Meta-Public-Quality, X-Amz-Meta-Public-Max-Bytes-Exceeded")
 			res.Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, HEAD")
 			res.Set("Access-Control-Allow-Origin", "*")
 			res.Set("Accept-Ranges", "bytes")
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+)
+
+// signCommand prints signed version of URL using signing config of bucket from URL path
+func signCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	configPath := flags.String("config", "/etc/mort/mort.yml", "Path to configuration")
+	rawURL := flags.String("url", "", "URL or path to sign, first path element is bucket name")
+	expires := flags.Duration("expires", 0, "validity of signed URL, 0 means no expiry")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *rawURL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	u, err := url.Parse(*rawURL)
+	if err != nil {
+		return err
+	}
+
+	mortConfig := config.Config{}
+	if err = mortConfig.Load(*configPath); err != nil {
+		return err
+	}
+
+	bucketName := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)[0]
+	bucket, ok := mortConfig.Buckets[bucketName]
+	if !ok || bucket.Transform == nil || bucket.Transform.Signing == nil {
+		return fmt.Errorf("bucket %s has no signing config", bucketName)
+	}
+
+	var expiresAt time.Time
+	if *expires > 0 {
+		expiresAt = time.Now().Add(*expires)
+	}
+
+	signed, err := object.SignURL(*rawURL, *bucket.Transform.Signing, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, signed)
+	return nil
+}
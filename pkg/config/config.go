@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -41,6 +42,9 @@ var storageKinds = []string{"local", "local-meta", "s3", "http", "b2", "noop", "
 // transformKind is list of available kinds of transforms
 var transformKinds = []string{"query", "presets", "presets-query", "tengo"}
 
+// pluginValidators functions checking configuration of plugins
+var pluginValidators = make(map[string]func(interface{}) error)
+
 // GetInstance return single instance of Config object
 func GetInstance() *Config {
 	once.Do(func() {
@@ -60,6 +64,11 @@ func RegisterTransformKind(kind string) {
 	transformKinds = append(transformKinds, kind)
 }
 
+// RegisterPluginValidator register function which checks plugin configuration in config validator
+func RegisterPluginValidator(name string, validate func(interface{}) error) {
+	pluginValidators[name] = validate
+}
+
 // Load reads config data from file
 // How configuration file should be formatted see README.md
 func (c *Config) Load(filePath string) error {
@@ -206,6 +215,12 @@ func (c *Config) validateTransform(bucketName string, bucket *Bucket) error {
 		}
 	}
 
+	for _, parentBucket := range transform.ParentBuckets {
+		if _, ok := c.Buckets[parentBucket]; !ok {
+			err = configInvalidError(fmt.Sprintf("%s - parentBuckets %s doesn't exist", errorMsgPrefix, parentBucket))
+		}
+	}
+
 	if transform.Kind == "presets" {
 		if strings.Index(transform.Path, "(?P<presetName>") == -1 {
 			err = configInvalidError(fmt.Sprintf("%s invalid transform regexp it should have capturing group for presetName `(?P<presetName>``", errorMsgPrefix))
@@ -216,9 +231,46 @@ func (c *Config) validateTransform(bucketName string, bucket *Bucket) error {
 		}
 	}
 
+	if transform.Signing != nil {
+		if transform.Signing.Secret == "" {
+			err = configInvalidError(fmt.Sprintf("%s - signing secret can't be empty", errorMsgPrefix))
+		}
+
+		if transform.Signing.Param == "" {
+			transform.Signing.Param = "sig"
+		}
+	}
+
+	if transform.Limits != nil {
+		if errLimits := transform.Limits.validate(); errLimits != nil {
+			err = configInvalidError(fmt.Sprintf("%s - invalid limits: %v", errorMsgPrefix, errLimits))
+		}
+	}
+
+	if imgproxy := transform.Imgproxy; imgproxy != nil {
+		_, errKey := hex.DecodeString(imgproxy.Key)
+		_, errSalt := hex.DecodeString(imgproxy.Salt)
+		switch {
+		case imgproxy.Insecure && (imgproxy.Key != "" || imgproxy.Salt != ""):
+			err = configInvalidError(fmt.Sprintf("%s - imgproxy insecure can't be used with key and salt", errorMsgPrefix))
+		case !imgproxy.Insecure && (errKey != nil || errSalt != nil || imgproxy.Key == "" || imgproxy.Salt == ""):
+			err = configInvalidError(fmt.Sprintf("%s - imgproxy key and salt are required and have to be hex encoded", errorMsgPrefix))
+		}
+	}
+
+	if transform.Thumbor != nil {
+		if transform.Thumbor.Key == "" && !transform.Thumbor.AllowUnsafe {
+			err = configInvalidError(fmt.Sprintf("%s - thumbor key can't be empty", errorMsgPrefix))
+		}
+
+		if transform.Thumbor.Key != "" && transform.Thumbor.AllowUnsafe {
+			err = configInvalidError(fmt.Sprintf("%s - thumbor allowUnsafe can't be used with key", errorMsgPrefix))
+		}
+	}
+
 	// in case of query string URLs, mort by default generate hash for object
 	// example https://mort.mkaciuba.com/demo/img.jpg?operation=rotate&angle=270 will be saved under this path /2c8/img/img.jpg-2c82757531989901
-	if transform.ResultKey == "" && (transform.Kind == "query" || transform.Kind == "presets-query") {
+	if transform.ResultKey == "" && (transform.Kind == "query" || transform.Kind == "presets-query" || transform.Kind == "imgproxy" || transform.Kind == "thumbor") {
 		bucket.Transform.ResultKey = "hashParent"
 	}
 
@@ -319,6 +371,14 @@ func (c *Config) validateServer() error {
 		c.Server.Placeholder.ContentType = http.DetectContentType(buf)
 	}
 
+	for name, pluginCfg := range c.Server.Plugins {
+		if validate, ok := pluginValidators[name]; ok {
+			if err := validate(pluginCfg); err != nil {
+				return configInvalidError(fmt.Sprintf("plugin %s has invalid configuration - %s", name, err))
+			}
+		}
+	}
+
 	// Validate idle cleanup configuration
 	if c.Server.IdleCleanup != nil && c.Server.IdleCleanup.Enabled {
 		if c.Server.IdleCleanup.IdleTimeoutMin == 0 {
@@ -373,6 +433,12 @@ func (c *Config) validate() error {
 			}
 		}
 
+		if bucket.Metadata != nil {
+			if errMetadata := bucket.Metadata.validate(); errMetadata != nil {
+				return configInvalidError(fmt.Sprintf("%s has invalid metadata config - %v", name, errMetadata))
+			}
+		}
+
 		// Validate and set GLACIER defaults
 		if bucket.Glacier != nil {
 			err = c.validateGlacier(name, bucket.Glacier)
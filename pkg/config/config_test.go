@@ -24,6 +24,36 @@ func TestInvalidParentStorageInTransform(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestSigningWithoutSecret(t *testing.T) {
+	c := Config{}
+	err := c.Load("testdata/signing-no-secret.yml")
+	assert.NotNil(t, err)
+}
+
+func TestInvalidLimits(t *testing.T) {
+	c := Config{}
+	err := c.Load("testdata/invalid-limits.yml")
+	assert.NotNil(t, err)
+}
+
+func TestInvalidMetadataPolicy(t *testing.T) {
+	c := Config{}
+	err := c.Load("testdata/invalid-metadata.yml")
+	assert.NotNil(t, err)
+}
+
+func TestInvalidImgproxyKey(t *testing.T) {
+	c := Config{}
+	err := c.Load("testdata/invalid-imgproxy-key.yml")
+	assert.NotNil(t, err)
+}
+
+func TestEmptyThumborKey(t *testing.T) {
+	c := Config{}
+	err := c.Load("testdata/empty-thumbor-key.yml")
+	assert.NotNil(t, err)
+}
+
 func TestNoBasicStorage(t *testing.T) {
 	c := Config{}
 	err := c.Load("testdata/no-basic-storage.yml")
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+)
+
+// LimitOperations list of operation names that can be used in limits
+var LimitOperations = []string{"resize", "crop", "resizeCropAuto", "extract", "extend", "watermark", "text", "blur", "sharpen", "modulate", "gamma", "tint", "rotate", "grayscale", "flip", "flop", "trim", "zoom", "frame", "info", "palette", "mask"}
+
+// MetadataPolicies list of metadata policies that can be used in presets and buckets
+var MetadataPolicies = []string{"keep", "strip", "icc", "nogps", "allowlist"}
+
+// CheckSize returns error when output dimensions are not allowed by limits
+// zero value means that dimension is not changed
+func (l *Limits) CheckSize(width, height int) error {
+	if l == nil {
+		return nil
+	}
+
+	if width != 0 && len(l.Widths) > 0 && !containsInt(l.Widths, width) {
+		return fmt.Errorf("width %d is not allowed, allowed widths %v", width, l.Widths)
+	}
+
+	if height != 0 && len(l.Heights) > 0 && !containsInt(l.Heights, height) {
+		return fmt.Errorf("height %d is not allowed, allowed heights %v", height, l.Heights)
+	}
+
+	if l.Step > 0 && (width%l.Step != 0 || height%l.Step != 0) {
+		return fmt.Errorf("width and height have to be multiple of %d", l.Step)
+	}
+
+	if l.MaxArea > 0 {
+		// when only one dimension is given output is treated as square
+		w, h := width, height
+		if w == 0 {
+			w = h
+		}
+		if h == 0 {
+			h = w
+		}
+
+		if w*h > l.MaxArea {
+			return fmt.Errorf("image area %dx%d exceeds limit of %d pixels", w, h, l.MaxArea)
+		}
+	}
+
+	return nil
+}
+
+// CheckDPI returns error when resolution of rasterized document is higher than allowed
+func (l *Limits) CheckDPI(dpi int) error {
+	if l == nil || l.MaxDPI == 0 || dpi <= l.MaxDPI {
+		return nil
+	}
+
+	return fmt.Errorf("dpi %d is not allowed, max dpi is %d", dpi, l.MaxDPI)
+}
+
+// CheckOperation returns error when operation is not allowed by limits
+func (l *Limits) CheckOperation(name string) error {
+	if l == nil || len(l.Operations) == 0 {
+		return nil
+	}
+
+	if containsString(l.Operations, name) {
+		return nil
+	}
+
+	return fmt.Errorf("operation %s is not allowed, allowed operations %v", name, l.Operations)
+}
+
+// CheckQuality returns error when quality is higher than allowed
+func (l *Limits) CheckQuality(quality int) error {
+	if l == nil || l.MaxQuality == 0 || quality <= l.MaxQuality {
+		return nil
+	}
+
+	return fmt.Errorf("quality %d is not allowed, max quality is %d", quality, l.MaxQuality)
+}
+
+func (l *Limits) validate() error {
+	for _, v := range append(append([]int{}, l.Widths...), l.Heights...) {
+		if v <= 0 {
+			return fmt.Errorf("allowed dimension %d has to be positive", v)
+		}
+	}
+
+	if l.Step < 0 || l.MaxArea < 0 || l.MaxFrames < 0 || l.MaxDPI < 0 || l.MaxRasterArea < 0 {
+		return fmt.Errorf("step, maxArea, maxFrames, maxDPI and maxRasterArea can't be negative")
+	}
+
+	if l.MaxQuality < 0 || l.MaxQuality > 100 {
+		return fmt.Errorf("maxQuality has to be between 1 and 100")
+	}
+
+	for _, op := range l.Operations {
+		if !containsString(LimitOperations, op) {
+			return fmt.Errorf("unknown operation %s", op)
+		}
+	}
+
+	return nil
+}
+
+func (m *MetadataPolicy) validate() error {
+	if !containsString(MetadataPolicies, m.Policy) {
+		return fmt.Errorf("unknown metadata policy %s", m.Policy)
+	}
+
+	if m.Policy == "allowlist" && len(m.Tags) == 0 {
+		return fmt.Errorf("allowlist metadata policy requires tags")
+	}
+
+	return nil
+}
+
+func containsInt(list []int, v int) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
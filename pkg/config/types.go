@@ -33,9 +33,14 @@ type Filters struct {
 		Width  int `yaml:"width"`
 		Height int `yaml:"height"`
 	} `yaml:"resizeCropAuto,omitempty"`
+	FocalPoint *struct {
+		X float64 `yaml:"x"` // 0..1 fraction of width or pixels
+		Y float64 `yaml:"y"` // 0..1 fraction of height or pixels
+	} `yaml:"focalPoint,omitempty"`
 	AutoRotate bool `yaml:"auto_rotate"`
 	Grayscale  bool `yaml:"grayscale"`
 	Strip      bool `yaml:"strip"`
+	Info       bool `yaml:"info"` // return image metadata as JSON
 	Blur       *struct {
 		Sigma   float64 `yaml:"sigma"`
 		MinAmpl float64 `yaml:"minAmpl"`
@@ -44,30 +49,183 @@ type Filters struct {
 		Image    string  `yaml:"image"`
 		Position string  `yaml:"position"`
 		Opacity  float32 `yaml:"opacity"`
+		Scale    float64 `yaml:"scale"`  // width relative to output width
+		Margin   int     `yaml:"margin"` // distance from edges or between tiles in px
+		Tile     bool    `yaml:"tile"`
 	} `yaml:"watermark,omitempty"`
+	Sharpen *struct {
+		Sigma  float64 `yaml:"sigma"`
+		Flat   float64 `yaml:"flat"`
+		Jagged float64 `yaml:"jagged"`
+	} `yaml:"sharpen,omitempty"`
+	Modulate *struct {
+		Brightness float64 `yaml:"brightness"` // multiplier, 0 means no change
+		Contrast   float64 `yaml:"contrast"`   // multiplier, 0 means no change
+		Saturation float64 `yaml:"saturation"` // multiplier, 0 means no change
+		Hue        float64 `yaml:"hue"`        // degrees
+	} `yaml:"modulate,omitempty"`
+	Gamma *struct {
+		Exponent float64 `yaml:"exponent"`
+	} `yaml:"gamma,omitempty"`
+	Tint *struct {
+		Color  string `yaml:"color"`
+		Shadow string `yaml:"shadow"`
+	} `yaml:"tint,omitempty"`
 	Rotate *struct {
 		Angle int `yaml:"angle"`
 	} `yaml:"rotate,omitempty"`
+	Flip bool `yaml:"flip"`
+	Flop bool `yaml:"flop"`
+	Trim *struct {
+		Threshold  float64 `yaml:"threshold"`
+		Background string  `yaml:"background"`
+	} `yaml:"trim,omitempty"`
+	Zoom *struct {
+		Factor int `yaml:"factor"`
+	} `yaml:"zoom,omitempty"`
+	Palette *struct {
+		Colors int `yaml:"colors"`
+	} `yaml:"palette,omitempty"`
+	Mask *struct {
+		Shape  string `yaml:"shape"`
+		Radius int    `yaml:"radius"`
+		Image  string `yaml:"image"`
+	} `yaml:"mask,omitempty"`
+	ColorProfile *struct {
+		Target string `yaml:"target"` // srgb, p3 or url of .icc file
+		Embed  *bool  `yaml:"embed"`  // keep ICC profile in output image, default true
+	} `yaml:"colorProfile,omitempty"`
+	Metadata *MetadataPolicy `yaml:"metadata,omitempty"` // which metadata are kept in output
+	Frame    *int            `yaml:"frame,omitempty"`    // use single frame of animated image
+	Page     *int            `yaml:"page,omitempty"`     // use single page of document
+	DPI      int             `yaml:"dpi"`                // resolution of rasterized document
+	Text     *struct {
+		Text     string  `yaml:"text"`
+		Font     string  `yaml:"font"`
+		Size     int     `yaml:"size"`
+		Color    string  `yaml:"color"`
+		Position string  `yaml:"position"`
+		Opacity  float32 `yaml:"opacity"`
+		DPI      int     `yaml:"dpi"`
+	} `yaml:"text,omitempty"`
+	Extend *struct {
+		Width      int    `yaml:"width"`
+		Height     int    `yaml:"height"`
+		Background string `yaml:"background"`
+		Mode       string `yaml:"mode"`
+	} `yaml:"extend,omitempty"`
+	// Pad alias of extend
+	Pad *struct {
+		Width      int    `yaml:"width"`
+		Height     int    `yaml:"height"`
+		Background string `yaml:"background"`
+		Mode       string `yaml:"mode"`
+	} `yaml:"pad,omitempty"`
+}
+
+// JpegOptions encoder options used when preset output format is jpeg
+type JpegOptions struct {
+	Progressive bool   `yaml:"progressive" json:"progressive"`
+	Subsample   string `yaml:"subsample" json:"subsample"`   // auto, on (4:2:0) or off (4:4:4)
+	Trellis     bool   `yaml:"trellis" json:"trellis"`       // trellis quantisation, requires libvips with mozjpeg
+	QuantTable  int    `yaml:"quantTable" json:"quantTable"` // quantization table 0 - 8, requires libvips with mozjpeg
+}
+
+// PngOptions encoder options used when preset output format is png
+type PngOptions struct {
+	Palette     bool `yaml:"palette" json:"palette"`         // save as 8 bit palette image
+	Compression int  `yaml:"compression" json:"compression"` // 1 (fastest) - 9 (smallest), default 6
+	Colors      int  `yaml:"colors" json:"colors"`           // number of colors of palette 2, 4, 16 or 256
+}
+
+// WebpOptions encoder options used when preset output format is webp
+type WebpOptions struct {
+	Lossless       bool `yaml:"lossless" json:"lossless"`
+	NearLossless   bool `yaml:"nearLossless" json:"nearLossless"`
+	Effort         int  `yaml:"effort" json:"effort"` // 1 (fastest) - 6 (slowest), default 4
+	SmartSubsample bool `yaml:"smartSubsample" json:"smartSubsample"`
+}
+
+// AvifOptions encoder options used when preset output format is avif
+type AvifOptions struct {
+	Speed    *int `yaml:"speed,omitempty" json:"speed,omitempty"` // 0 (slowest) - 8 (fastest), default 5
+	Lossless bool `yaml:"lossless" json:"lossless"`
+}
+
+// JxlOptions encoder options used when preset output format is jxl
+type JxlOptions struct {
+	Effort   int  `yaml:"effort" json:"effort"` // 1 (fastest) - 9 (slowest)
+	Lossless bool `yaml:"lossless" json:"lossless"`
+}
+
+// MaxBytesOptions limit size of output image
+type MaxBytesOptions struct {
+	Bytes     int  `yaml:"bytes" json:"bytes"`         // max size of output image
+	Downscale bool `yaml:"downscale" json:"downscale"` // make image smaller when it doesn't fit with lowest quality
 }
 
 // Preset describe properties of transform preset
 type Preset struct {
-	Quality int     `yaml:"quality" json:"quality"`
-	Format  string  `yaml:"format" json:"format"`
-	Filters Filters `yaml:"filters" json:"filters"`
+	Quality  int              `yaml:"quality" json:"quality"`
+	Format   string           `yaml:"format" json:"format"`
+	Jpeg     *JpegOptions     `yaml:"jpeg,omitempty" json:"jpeg,omitempty"`
+	Png      *PngOptions      `yaml:"png,omitempty" json:"png,omitempty"`
+	Webp     *WebpOptions     `yaml:"webp,omitempty" json:"webp,omitempty"`
+	Avif     *AvifOptions     `yaml:"avif,omitempty" json:"avif,omitempty"`
+	Jxl      *JxlOptions      `yaml:"jxl,omitempty" json:"jxl,omitempty"`
+	MaxBytes *MaxBytesOptions `yaml:"maxBytes,omitempty" json:"maxBytes,omitempty"`
+	Filters  Filters          `yaml:"filters" json:"filters"`
+}
+
+// Signing describe signature required for transform URLs
+type Signing struct {
+	Secret       string `yaml:"secret"`       // HMAC-SHA256 secret
+	Param        string `yaml:"param"`        // name of query parameter with signature (default: sig)
+	ExpiresParam string `yaml:"expiresParam"` // name of query parameter with unix expiry time, empty disables expiry
+}
+
+// Limits describe which transforms are allowed for bucket
+type Limits struct {
+	Widths        []int    `yaml:"widths"`        // allowed output widths
+	Heights       []int    `yaml:"heights"`       // allowed output heights
+	Step          int      `yaml:"step"`          // output dimensions have to be multiple of step
+	MaxArea       int      `yaml:"maxArea"`       // max width * height of output image
+	Operations    []string `yaml:"operations"`    // allowed operations
+	MaxQuality    int      `yaml:"maxQuality"`    // max output quality
+	MaxFrames     int      `yaml:"maxFrames"`     // max number of processed frames of animated image
+	MaxDPI        int      `yaml:"maxDPI"`        // max resolution of rasterized documents
+	MaxRasterArea int      `yaml:"maxRasterArea"` // max width * height of rasterized document page or extracted frame
+}
+
+// ImgproxyCfg describe keys used for imgproxy URL signatures
+type ImgproxyCfg struct {
+	Key      string `yaml:"key"`      // hex encoded key
+	Salt     string `yaml:"salt"`     // hex encoded salt
+	Insecure bool   `yaml:"insecure"` // accept URLs with any signature, only allowed when key and salt are empty
+}
+
+// ThumborCfg describe key used for thumbor URL signatures
+type ThumborCfg struct {
+	Key         string `yaml:"key"`         // security key, signed URLs are verified with it
+	AllowUnsafe bool   `yaml:"allowUnsafe"` // accept URLs with "unsafe" signature, only allowed when key is empty
 }
 
 // Transform describe transform for bucket
 type Transform struct {
-	Path          string `yaml:"path"`
-	ParentStorage string `yaml:"parentStorage"`
-	ParentBucket  string `yaml:"parentBucket"`
+	Path          string   `yaml:"path"`
+	ParentStorage string   `yaml:"parentStorage"`
+	ParentBucket  string   `yaml:"parentBucket"`
+	ParentBuckets []string `yaml:"parentBuckets"`
 	PathRegexp    *regexp.Regexp
 	Kind          string            `yaml:"kind"`
 	Presets       map[string]Preset `yaml:"presets"`
 	CheckParent   bool              `yaml:"checkParent"`
 	ResultKey     string            `yaml:"resultKey"`
 	TengoPath     string            `yaml:"tengoPath"`
+	Signing       *Signing          `yaml:"signing,omitempty"`
+	Limits        *Limits           `yaml:"limits,omitempty"`
+	Imgproxy      *ImgproxyCfg      `yaml:"imgproxy,omitempty"`
+	Thumbor       *ThumborCfg       `yaml:"thumbor,omitempty"`
 	TengoScript   *tengo.Compiled
 }
 
@@ -76,11 +234,16 @@ func (t *Transform) ForParser() *Transform {
 		Path:          t.Path,
 		ParentStorage: t.ParentStorage,
 		ParentBucket:  t.ParentBucket,
+		ParentBuckets: t.ParentBuckets,
 		PathRegexp:    t.PathRegexp,
 		Kind:          t.Kind,
 		Presets:       t.Presets,
 		CheckParent:   t.CheckParent,
 		ResultKey:     t.ResultKey,
+		Signing:       t.Signing,
+		Limits:        t.Limits,
+		Imgproxy:      t.Imgproxy,
+		Thumbor:       t.Thumbor,
 	}
 
 }
@@ -162,10 +325,23 @@ type Bucket struct {
 	Storages  StorageTypes      `yaml:"storages"`
 	Keys      []S3Key           `yaml:"keys"`
 	Headers   map[string]string `yaml:"headers"`
-	Glacier   *GlacierCfg       `yaml:"glacier,omitempty"` // GLACIER restore configuration
+	Glacier   *GlacierCfg       `yaml:"glacier,omitempty"`  // GLACIER restore configuration
+	Metadata  *MetadataCfg      `yaml:"metadata,omitempty"` // metadata policy of images in bucket
 	Name      string
 }
 
+// MetadataPolicy describes which metadata of image are kept in output
+type MetadataPolicy struct {
+	Policy string   `yaml:"policy"` // keep, strip, icc, nogps or allowlist
+	Tags   []string `yaml:"tags"`   // EXIF tags kept by allowlist policy, "xmp" and "iptc" keep whole packets
+}
+
+// MetadataCfg configure metadata policy used for all transforms of bucket
+type MetadataCfg struct {
+	MetadataPolicy `yaml:",inline"`
+	OnUpload       bool `yaml:"onUpload"` // apply policy also to images uploaded to bucket
+}
+
 // HeaderYaml allow you to override response headers
 type HeaderYaml struct {
 	StatusCodes []int             `yaml:"statusCodes"`
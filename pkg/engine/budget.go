@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
+)
+
+const (
+	// maxBudgetIterations max number of encodings done while searching for image fitting in max bytes
+	maxBudgetIterations = 8
+	// minBudgetQuality lowest quality used by search
+	minBudgetQuality = 10
+	// defaultBudgetQuality highest quality used by search when transform doesn't set quality
+	defaultBudgetQuality = 95
+	// budgetScaleMargin downscaled image is a bit smaller than estimated, so it fits in max bytes in next attempt
+	budgetScaleMargin = 0.9
+)
+
+// budgetResult is image found by search for output fitting in max bytes
+type budgetResult struct {
+	buf     []byte // encoded image
+	source  []byte // lossless image which was encoded, it is smaller than input when image was downscaled
+	quality int
+	fits    bool
+}
+
+// fitBudget encodes image with highest quality for which output is not larger than enc.MaxBytes
+// when image doesn't fit with lowest quality it is downscaled (if enc.Downscale is set) or smallest output is returned
+func fitBudget(buf []byte, enc transforms.Encoder) (budgetResult, error) {
+	maxQuality := enc.Quality
+	if maxQuality == 0 {
+		maxQuality = defaultBudgetQuality
+	}
+
+	// quality doesn't change size of lossless images, only downscaling can help
+	minQuality := minBudgetQuality
+	if enc.Format == "png" || enc.Lossless {
+		minQuality = maxQuality
+	}
+
+	iterations := 0
+	encode := func(source []byte, quality int) (budgetResult, error) {
+		iterations++
+		enc.Quality = quality
+		out, err := encodeImage(source, enc)
+		return budgetResult{buf: out, source: source, quality: quality, fits: len(out) <= enc.MaxBytes}, err
+	}
+
+	var result budgetResult
+	for {
+		// highest quality is checked first as usually image fits without changes
+		best, err := encode(buf, maxQuality)
+		if err != nil || best.fits {
+			return best, err
+		}
+
+		result = best
+		if minQuality < maxQuality && iterations < maxBudgetIterations {
+			result, err = encode(buf, minQuality)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		if result.fits {
+			break
+		}
+
+		if !enc.Downscale || iterations >= maxBudgetIterations {
+			return result, nil
+		}
+
+		// size of image is roughly proportional to number of pixels
+		buf, err = scaleImage(buf, math.Sqrt(float64(enc.MaxBytes)/float64(len(result.buf)))*budgetScaleMargin)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// binary search between lowest quality which fits and highest which doesn't
+	lo, hi := result.quality+1, maxQuality-1
+	for lo <= hi && iterations < maxBudgetIterations {
+		quality := (lo + hi + 1) / 2
+		candidate, err := encode(result.source, quality)
+		if err != nil {
+			return candidate, err
+		}
+
+		if candidate.fits {
+			result = candidate
+			lo = quality + 1
+		} else {
+			hi = quality - 1
+		}
+	}
+
+	return result, nil
+}
+
+// scaleImage resize image by given factor, result is lossless image
+func scaleImage(buf []byte, factor float64) ([]byte, error) {
+	size, err := bimg.Size(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	longer := size.Width
+	if size.Height > longer {
+		longer = size.Height
+	}
+
+	return downscale(buf, int(math.Max(1, float64(longer)*factor)), bimg.Options{Type: bimg.PNG})
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// paletteBitDepth returns bit depth of PNG palette with given number of colors (2, 4, 16 or 256)
+func paletteBitDepth(colors int) int {
+	switch colors {
+	case 2:
+		return 1
+	case 4:
+		return 2
+	case 16:
+		return 4
+	default:
+		return 8
+	}
+}
+
+// encoderSuffix returns libvips save suffix with encoder options for jpeg, png, webp, avif, heif or gif
+func encoderSuffix(enc transforms.Encoder) string {
+	var opts []string
+	add := func(name string, value int) {
+		opts = append(opts, name+"="+strconv.Itoa(value))
+	}
+	flag := func(name string, set bool) {
+		if set {
+			opts = append(opts, name+"=true")
+		}
+	}
+
+	var suffix string
+	switch enc.Format {
+	case "jpeg":
+		suffix = ".jpg"
+		flag("optimize_coding", true)
+		flag("interlace", enc.Interlace)
+		if enc.Subsample != "" {
+			opts = append(opts, "subsample_mode="+enc.Subsample)
+		}
+		flag("trellis_quant", enc.Trellis)
+		if enc.QuantTable != 0 {
+			add("quant_table", enc.QuantTable)
+		}
+	case "png":
+		suffix = ".png"
+		flag("interlace", enc.Interlace)
+		if enc.Compression != 0 {
+			add("compression", enc.Compression)
+		}
+		flag("palette", enc.Palette)
+		if enc.Colors != 0 {
+			add("bitdepth", paletteBitDepth(enc.Colors))
+		}
+	case "webp":
+		suffix = ".webp"
+		flag("lossless", enc.Lossless)
+		flag("near_lossless", enc.NearLossless)
+		if enc.Effort != 0 {
+			add("reduction_effort", enc.Effort)
+		}
+		flag("smart_subsample", enc.SmartSubsample)
+	case "avif":
+		suffix = ".avif"
+		flag("lossless", enc.Lossless)
+		add("speed", enc.Speed)
+	case "heif":
+		suffix = ".heic"
+		flag("lossless", enc.Lossless)
+	case "gif":
+		suffix = ".gif"
+	}
+
+	// quality of png is used only for palette quantisation, gif encoder doesn't use it
+	if enc.Quality != 0 && enc.Format != "gif" && (enc.Format != "png" || enc.Palette) {
+		add("Q", enc.Quality)
+	}
+	flag("strip", enc.StripMetadata)
+
+	return suffix + "[" + strings.Join(opts, ",") + "]"
+}
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderSuffix(t *testing.T) {
+	tests := []struct {
+		enc      transforms.Encoder
+		expected string
+	}{
+		{transforms.Encoder{Format: "jpeg", Quality: 80, Interlace: true, Subsample: "off", Trellis: true, QuantTable: 3},
+			".jpg[optimize_coding=true,interlace=true,subsample_mode=off,trellis_quant=true,quant_table=3,Q=80]"},
+		{transforms.Encoder{Format: "png", Quality: 80, Compression: 9},
+			".png[compression=9]"},
+		{transforms.Encoder{Format: "png", Quality: 80, Palette: true, Colors: 16, StripMetadata: true},
+			".png[palette=true,bitdepth=4,Q=80,strip=true]"},
+		{transforms.Encoder{Format: "webp", NearLossless: true, Effort: 6, SmartSubsample: true},
+			".webp[near_lossless=true,reduction_effort=6,smart_subsample=true]"},
+		{transforms.Encoder{Format: "avif", Quality: 50, Speed: 5},
+			".avif[speed=5,Q=50]"},
+		{transforms.Encoder{Format: "heif", Quality: 60},
+			".heic[Q=60]"},
+		{transforms.Encoder{Format: "gif", Quality: 60, StripMetadata: true},
+			".gif[strip=true]"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, encoderSuffix(tt.enc))
+	}
+}
+
+func TestPaletteBitDepth(t *testing.T) {
+	assert.Equal(t, 1, paletteBitDepth(2))
+	assert.Equal(t, 2, paletteBitDepth(4))
+	assert.Equal(t, 4, paletteBitDepth(16))
+	assert.Equal(t, 8, paletteBitDepth(256))
+}
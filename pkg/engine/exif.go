@@ -0,0 +1,315 @@
+package engine
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// numbers of EXIF IFDs, they are the same as in libvips field names (exif-ifdN-Name)
+const (
+	exifIFD0 = iota
+	exifIFD1
+	exifIFDExif
+	exifIFDGPS
+	exifIFDInterop
+)
+
+// exifPointers maps tags which point to sub IFD to number of that IFD
+var exifPointers = map[uint16]int{
+	0x8769: exifIFDExif,
+	0x8825: exifIFDGPS,
+	0xA005: exifIFDInterop,
+}
+
+// exifTypeSizes size in bytes of single value of given TIFF type
+var exifTypeSizes = map[uint16]uint64{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4,
+}
+
+// exifTagNames names of EXIF tags (as in libexif) stored in IFD0, IFD1 and EXIF IFD
+var exifTagNames = map[uint16]string{
+	0x0100: "ImageWidth",
+	0x0101: "ImageLength",
+	0x0102: "BitsPerSample",
+	0x0103: "Compression",
+	0x0106: "PhotometricInterpretation",
+	0x010E: "ImageDescription",
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0111: "StripOffsets",
+	0x0112: "Orientation",
+	0x0115: "SamplesPerPixel",
+	0x0116: "RowsPerStrip",
+	0x0117: "StripByteCounts",
+	0x011A: "XResolution",
+	0x011B: "YResolution",
+	0x011C: "PlanarConfiguration",
+	0x0128: "ResolutionUnit",
+	0x012D: "TransferFunction",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x013E: "WhitePoint",
+	0x013F: "PrimaryChromaticities",
+	0x0201: "JPEGInterchangeFormat",
+	0x0202: "JPEGInterchangeFormatLength",
+	0x0211: "YCbCrCoefficients",
+	0x0212: "YCbCrSubSampling",
+	0x0213: "YCbCrPositioning",
+	0x0214: "ReferenceBlackWhite",
+	0x8298: "Copyright",
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8822: "ExposureProgram",
+	0x8824: "SpectralSensitivity",
+	0x8827: "ISOSpeedRatings",
+	0x8830: "SensitivityType",
+	0x9000: "ExifVersion",
+	0x9003: "DateTimeOriginal",
+	0x9004: "DateTimeDigitized",
+	0x9010: "OffsetTime",
+	0x9011: "OffsetTimeOriginal",
+	0x9012: "OffsetTimeDigitized",
+	0x9101: "ComponentsConfiguration",
+	0x9102: "CompressedBitsPerPixel",
+	0x9201: "ShutterSpeedValue",
+	0x9202: "ApertureValue",
+	0x9203: "BrightnessValue",
+	0x9204: "ExposureBiasValue",
+	0x9205: "MaxApertureValue",
+	0x9206: "SubjectDistance",
+	0x9207: "MeteringMode",
+	0x9208: "LightSource",
+	0x9209: "Flash",
+	0x920A: "FocalLength",
+	0x9214: "SubjectArea",
+	0x927C: "MakerNote",
+	0x9286: "UserComment",
+	0x9290: "SubSecTime",
+	0x9291: "SubSecTimeOriginal",
+	0x9292: "SubSecTimeDigitized",
+	0xA000: "FlashPixVersion",
+	0xA001: "ColorSpace",
+	0xA002: "PixelXDimension",
+	0xA003: "PixelYDimension",
+	0xA004: "RelatedSoundFile",
+	0xA20E: "FocalPlaneXResolution",
+	0xA20F: "FocalPlaneYResolution",
+	0xA210: "FocalPlaneResolutionUnit",
+	0xA215: "ExposureIndex",
+	0xA217: "SensingMethod",
+	0xA300: "FileSource",
+	0xA301: "SceneType",
+	0xA401: "CustomRendered",
+	0xA402: "ExposureMode",
+	0xA403: "WhiteBalance",
+	0xA404: "DigitalZoomRatio",
+	0xA405: "FocalLengthIn35mmFilm",
+	0xA406: "SceneCaptureType",
+	0xA407: "GainControl",
+	0xA408: "Contrast",
+	0xA409: "Saturation",
+	0xA40A: "Sharpness",
+	0xA40C: "SubjectDistanceRange",
+	0xA420: "ImageUniqueID",
+	0xA430: "CameraOwnerName",
+	0xA431: "BodySerialNumber",
+	0xA432: "LensSpecification",
+	0xA433: "LensMake",
+	0xA434: "LensModel",
+	0xA435: "LensSerialNumber",
+}
+
+// exifGPSTagNames names of EXIF tags stored in GPS IFD
+var exifGPSTagNames = map[uint16]string{
+	0x00: "GPSVersionID",
+	0x01: "GPSLatitudeRef",
+	0x02: "GPSLatitude",
+	0x03: "GPSLongitudeRef",
+	0x04: "GPSLongitude",
+	0x05: "GPSAltitudeRef",
+	0x06: "GPSAltitude",
+	0x07: "GPSTimeStamp",
+	0x08: "GPSSatellites",
+	0x09: "GPSStatus",
+	0x0A: "GPSMeasureMode",
+	0x0B: "GPSDOP",
+	0x0C: "GPSSpeedRef",
+	0x0D: "GPSSpeed",
+	0x0E: "GPSTrackRef",
+	0x0F: "GPSTrack",
+	0x10: "GPSImgDirectionRef",
+	0x11: "GPSImgDirection",
+	0x12: "GPSMapDatum",
+	0x13: "GPSDestLatitudeRef",
+	0x14: "GPSDestLatitude",
+	0x15: "GPSDestLongitudeRef",
+	0x16: "GPSDestLongitude",
+	0x17: "GPSDestBearingRef",
+	0x18: "GPSDestBearing",
+	0x19: "GPSDestDistanceRef",
+	0x1A: "GPSDestDistance",
+	0x1B: "GPSProcessingMethod",
+	0x1C: "GPSAreaInformation",
+	0x1D: "GPSDateStamp",
+	0x1E: "GPSDifferential",
+}
+
+// exifInteropTagNames names of EXIF tags stored in interoperability IFD
+var exifInteropTagNames = map[uint16]string{
+	0x01: "InteroperabilityIndex",
+	0x02: "InteroperabilityVersion",
+}
+
+var errInvalidExif = errors.New("invalid exif data")
+
+// exifTagName returns name of tag in given IFD or empty string for unknown tags
+func exifTagName(ifd int, tag uint16) string {
+	switch ifd {
+	case exifIFDGPS:
+		return exifGPSTagNames[tag]
+	case exifIFDInterop:
+		return exifInteropTagNames[tag]
+	default:
+		return exifTagNames[tag]
+	}
+}
+
+// exifFilter removes tags from TIFF structure of EXIF data without rebuilding it
+// removed entries and their values are cleared, so offsets of kept values don't change
+type exifFilter struct {
+	buf     []byte
+	order   binary.ByteOrder
+	keep    func(ifd int, tag uint16) bool
+	visited map[uint32]bool
+}
+
+// filterExif returns copy of EXIF data (TIFF header and IFDs) with tags for which keep returns false removed
+// nil is returned when no tag is left
+func filterExif(data []byte, keep func(ifd int, tag uint16) bool) ([]byte, error) {
+	if len(data) < 8 {
+		return nil, errInvalidExif
+	}
+
+	f := exifFilter{buf: make([]byte, len(data)), keep: keep, visited: map[uint32]bool{}}
+	copy(f.buf, data)
+	switch string(data[:4]) {
+	case "II*\x00":
+		f.order = binary.LittleEndian
+	case "MM\x00*":
+		f.order = binary.BigEndian
+	default:
+		return nil, errInvalidExif
+	}
+
+	ifd0 := f.order.Uint32(f.buf[4:])
+	left, next, err := f.filterIFD(ifd0, exifIFD0)
+	if err != nil {
+		return nil, err
+	}
+
+	if next != 0 {
+		thumbLeft, _, err := f.filterIFD(next, exifIFD1)
+		if err != nil {
+			return nil, err
+		}
+
+		// IFD0 header is cleared when it has no tags, so link to IFD1 is written again
+		if thumbLeft == 0 {
+			next = 0
+		}
+		f.setNext(ifd0, left, next)
+		left += thumbLeft
+	}
+
+	if left == 0 {
+		return nil, nil
+	}
+
+	return f.buf, nil
+}
+
+// filterIFD removes tags of IFD at offset, it returns number of kept tags and offset of next IFD
+func (f *exifFilter) filterIFD(offset uint32, ifd int) (int, uint32, error) {
+	start := uint64(offset)
+	if f.visited[offset] || start+2 > uint64(len(f.buf)) {
+		return 0, 0, errInvalidExif
+	}
+	f.visited[offset] = true
+
+	count := uint64(f.order.Uint16(f.buf[start:]))
+	end := start + 2 + count*12
+	if end+4 > uint64(len(f.buf)) {
+		return 0, 0, errInvalidExif
+	}
+	next := f.order.Uint32(f.buf[end:])
+
+	var thumbOffset, thumbLength uint32
+	kept := uint64(0)
+	for i := uint64(0); i < count; i++ {
+		entry := f.buf[start+2+i*12 : start+14+i*12]
+		tag := f.order.Uint16(entry)
+		if ifd == exifIFD1 && tag == 0x0202 {
+			thumbLength = f.order.Uint32(entry[8:])
+		}
+
+		var keepEntry bool
+		if sub, ok := exifPointers[tag]; ok {
+			left, _, err := f.filterIFD(f.order.Uint32(entry[8:]), sub)
+			if err != nil {
+				return 0, 0, err
+			}
+			keepEntry = left > 0
+		} else {
+			keepEntry = f.keep(ifd, tag)
+		}
+
+		if !keepEntry {
+			f.clearValue(entry)
+			if ifd == exifIFD1 && tag == 0x0201 {
+				thumbOffset = f.order.Uint32(entry[8:])
+			}
+			continue
+		}
+
+		copy(f.buf[start+2+kept*12:], entry)
+		kept++
+	}
+
+	if thumbOffset != 0 {
+		f.clear(uint64(thumbOffset), uint64(thumbLength))
+	}
+
+	f.setNext(offset, int(kept), next)
+	f.clear(start+2+kept*12+4, (count-kept)*12)
+	if kept == 0 {
+		f.clear(start, 6)
+	}
+
+	return int(kept), next, nil
+}
+
+// setNext writes number of entries of IFD at offset and pointer to next IFD
+func (f *exifFilter) setNext(offset uint32, entries int, next uint32) {
+	f.order.PutUint16(f.buf[offset:], uint16(entries))
+	f.order.PutUint32(f.buf[uint64(offset)+2+uint64(entries)*12:], next)
+}
+
+// clearValue clears value of IFD entry which is stored outside of it
+func (f *exifFilter) clearValue(entry []byte) {
+	size := exifTypeSizes[f.order.Uint16(entry[2:])] * uint64(f.order.Uint32(entry[4:]))
+	if size > 4 {
+		f.clear(uint64(f.order.Uint32(entry[8:])), size)
+	}
+}
+
+// clear sets length bytes from offset to zero, ranges outside of buffer are ignored
+func (f *exifFilter) clear(offset, length uint64) {
+	if offset+length > uint64(len(f.buf)) || offset < 8 {
+		return
+	}
+
+	for i := offset; i < offset+length; i++ {
+		f.buf[i] = 0
+	}
+}
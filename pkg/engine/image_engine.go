@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -39,8 +41,82 @@ func (c *ImageEngine) Process(obj *object.FileObject, trans []transforms.Transfo
 
 	// Cache image type name to avoid repeated detection
 	imageType := bimg.DetermineImageTypeName(buf)
+	transLen := len(trans)
+	var encoder transforms.Encoder
+	var encode bool
+	animated := (imageType == "gif" || imageType == "webp") && imagePages(buf) > 1
+
+	for transIdx, tran := range trans {
+		// metadata or palette of current image is returned instead of image, remaining operations are skipped
+		if tran.HasInfo() {
+			body, err := describeImage(buf)
+			return c.jsonResponse(obj, body, err)
+		}
+
+		if colors, ok := tran.PaletteColors(); ok {
+			body, err := describePalette(buf, colors)
+			return c.jsonResponse(obj, body, err)
+		}
+
+		// documents are always rasterized, by default first page is used
+		// other images are changed only when they have more than one frame, first frame of single frame image is image itself
+		if frame, ok := tran.FrameIndex(); ok || imageType == "pdf" {
+			pages := imagePages(buf)
+			if frame >= pages {
+				err = fmt.Errorf("frame %d is out of range, image has %d frames", frame, pages)
+				return response.NewError(400, err), err
+			}
+
+			if pages > 1 || imageType == "pdf" {
+				dpi := 0
+				if imageType == "pdf" {
+					dpi = tran.DocumentDPI()
+				}
+				buf, err = extractFrame(buf, frame, dpi, tran.RasterArea())
+				if errors.Is(err, errRasterAreaExceeded) {
+					return response.NewError(400, err), err
+				}
+				if err != nil {
+					monitoring.Log().Error("ImageEngine unable to extract frame", obj.LogData(zap.Int("frame", frame), zap.Error(err))...)
+					return response.NewError(500, err), err
+				}
+			}
+			animated = false
+		}
+
+		// all frames of animation are processed by libvips, other operations use only first frame
+		if anim, ok := tran.Animation(imageType); ok && animated {
+			buf, err = processAnimated(buf, anim)
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to process animation", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+			imageType = anim.Format
+			continue
+		}
+		animated = false
+
+		// colors are converted before other operations, result is lossless image so bimg restores source format
+		if srcMeta, metaErr := bimg.Metadata(buf); metaErr == nil {
+			profile, ok, err := tran.ColorProfileOptions(srcMeta.Space)
+			if err == nil && ok {
+				buf, err = applyColorProfile(buf, profile)
+			}
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to convert color profile", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+		}
+
+		// canvas is extended before other operations, image type is not updated so bimg encodes result in source format
+		if ext, ok := tran.ExtendOptions(); ok {
+			buf, err = extendImage(buf, ext)
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to extend image", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+		}
 
-	for _, tran := range trans {
 		image := bimg.NewImage(buf)
 		meta, err := image.Metadata()
 		if err != nil {
@@ -67,25 +143,118 @@ func (c *ImageEngine) Process(obj *object.FileObject, trans []transforms.Transfo
 			}
 		}
 		// Update image type for next transform (format may have changed)
+		inputType := imageType
 		imageType = bimg.DetermineImageTypeName(buf)
+		// engine operations produce lossless intermediate image which is encoded once at the end
+		if adj, ok := tran.Adjustments(); ok {
+			buf, err = adjustImage(buf, adj)
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to adjust image", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+		}
+		if overlay, ok := tran.TextOverlay(); ok {
+			buf, err = drawText(buf, overlay)
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to draw text", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+		}
+		mask, ok, err := tran.MaskOptions()
+		if err == nil && ok {
+			buf, err = applyMask(buf, mask)
+		}
+		if err != nil {
+			monitoring.Log().Error("ImageEngine unable to mask image", obj.LogData(zap.Error(err))...)
+			return response.NewError(500, err), err
+		}
+		if policy, ok := tran.MetadataOptions(); ok {
+			buf, err = filterMetadata(buf, policy, intermediateSuffix)
+			if err != nil {
+				monitoring.Log().Error("ImageEngine unable to filter metadata", obj.LogData(zap.Error(err))...)
+				return response.NewError(500, err), err
+			}
+		}
+		// output of engine encoder is created only by the last transform, other transforms pass intermediate image
+		// with format in which it should be saved, so next transform restores it
+		encoder, encode = tran.Encoder(inputType)
+		if transIdx < transLen-1 {
+			if encode && !isPlaceholder(encoder.Format) {
+				imageType = encoder.Format
+			}
+			encode = false
+		}
+	}
+
+	meta, metaErr := bimg.Metadata(buf)
+	quality := 0
+	fits := true
+	if encode && encoder.MaxBytes > 0 {
+		var result budgetResult
+		result, err = fitBudget(buf, encoder)
+		if err == nil {
+			if !result.fits {
+				monitoring.Log().Warn("ImageEngine unable to fit image in max bytes", obj.LogData(zap.Int("maxBytes", encoder.MaxBytes), zap.Int("size", len(result.buf)))...)
+			}
+			buf, quality, fits = result.buf, result.quality, result.fits
+			// image could be downscaled
+			meta, metaErr = bimg.Metadata(result.source)
+		}
+	} else if encode {
+		buf, err = encodeImage(buf, encoder)
+	}
+	if err != nil {
+		monitoring.Log().Error("ImageEngine unable to encode image", obj.LogData(zap.String("format", encoder.Format), zap.Error(err))...)
+		return response.NewError(500, err), err
+	}
+	if encode {
+		imageType = encoder.Format
 	}
 
 	bodyHash := md5.New()
 	bodyHash.Write(buf)
 
 	res := response.NewBuf(200, buf)
-	res.SetContentType("image/" + bimg.DetermineImageTypeName(buf))
+	if isPlaceholder(imageType) {
+		res.SetContentType("text/plain; charset=utf-8")
+	} else {
+		res.SetContentType("image/" + imageType)
+	}
 	//res.Set("cache-control", "max-age=6000, public")
 	res.Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
 	res.Set("ETag", hex.EncodeToString(bodyHash.Sum(nil)))
-	meta, err := bimg.Metadata(buf)
-	if err == nil {
+	if metaErr == nil {
 		res.Set("x-amz-meta-public-width", strconv.Itoa(meta.Size.Width))
 		res.Set("x-amz-meta-public-height", strconv.Itoa(meta.Size.Height))
 
 	} else {
-		monitoring.Log().Warn("ImageEngine/process unable to get metadata", obj.LogData(zap.Error(err))...)
+		monitoring.Log().Warn("ImageEngine/process unable to get metadata", obj.LogData(zap.Error(metaErr))...)
+	}
+
+	// quality chosen for max bytes
+	if quality != 0 {
+		res.Set("x-amz-meta-public-quality", strconv.Itoa(quality))
+	}
+	if !fits {
+		res.Set("x-amz-meta-public-max-bytes-exceeded", "true")
+	}
+
+	return res, nil
+}
+
+// jsonResponse create response with JSON document describing image
+func (c *ImageEngine) jsonResponse(obj *object.FileObject, body []byte, err error) (*response.Response, error) {
+	if err != nil {
+		monitoring.Log().Error("ImageEngine unable to describe image", obj.LogData(zap.Error(err))...)
+		return response.NewError(500, err), err
 	}
 
+	bodyHash := md5.New()
+	bodyHash.Write(body)
+
+	res := response.NewBuf(200, body)
+	res.SetContentType("application/json")
+	res.Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	res.Set("ETag", hex.EncodeToString(bodyHash.Sum(nil)))
 	return res, nil
 }
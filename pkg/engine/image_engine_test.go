@@ -1,12 +1,15 @@
 package engine
 
 import (
+	"encoding/json"
 	"github.com/aldor007/mort/pkg/config"
 	"github.com/aldor007/mort/pkg/object"
 	"github.com/aldor007/mort/pkg/response"
 	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -325,6 +328,689 @@ func TestImageEngine_Process_Blur(t *testing.T) {
 	assert.Equal(t, 200, res.StatusCode)
 }
 
+func TestImageEngine_Process_Text(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.Transforms{}
+	trans.Resize(100, 70, false, false, false)
+	assert.Nil(t, trans.Text("mort", "", 12, "white", "bottom-right", 0.8, 0))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err, "text should be drawn")
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+	assert.Equal(t, "100", res.Headers.Get("x-amz-meta-public-width"))
+}
+
+func TestImageEngine_Process_TextMarkup(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.Transforms{}
+	trans.Resize(100, 70, false, false, false)
+	assert.Nil(t, trans.Text(`<span size="9999999">a & b</span>`, "", 12, "white", "bottom-right", 0.8, 0))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err, "text with markup characters should be drawn as plain text")
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "100", res.Headers.Get("x-amz-meta-public-width"))
+}
+
+func TestTextMarkup(t *testing.T) {
+	assert.Equal(t, "a &amp; b", textMarkup("a & b"))
+	assert.Equal(t, "&lt;span size=&#34;9999999&#34;&gt;x&lt;/span&gt;", textMarkup(`<span size="9999999">x</span>`))
+	assert.Equal(t, "mort", textMarkup("mort"))
+}
+
+func TestImageEngine_Process_Extend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		background  string
+		mode        string
+		format      string
+		contentType string
+	}{
+		{"should extend with color", "red", "", "", "image/jpeg"},
+		{"should extend with transparent background", "transparent", "color", "png", "image/png"},
+		{"should extend with blurred image", "", "blur", "", "image/jpeg"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.Transforms{}
+			assert.Nil(t, trans.Extend(300, 300, tt.background, tt.mode))
+			if tt.format != "" {
+				assert.Nil(t, trans.Format(tt.format))
+			}
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err, "image should be extended")
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, tt.contentType, res.Headers.Get("content-type"))
+		})
+	}
+}
+
+func TestImageEngine_Process_Adjustments(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.Transforms{}
+	trans.Resize(100, 70, false, false, false)
+	assert.Nil(t, trans.Sharpen(1, 0, 0))
+	assert.Nil(t, trans.Modulate(1.1, 1.2, 0.8, 30))
+	assert.Nil(t, trans.Gamma(2.2))
+	assert.Nil(t, trans.Tint("#704214", ""))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err, "image should be adjusted")
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+	assert.Equal(t, "100", res.Headers.Get("x-amz-meta-public-width"))
+}
+
+func TestImageEngine_Process_AdjustmentsChain(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	// first transform passes lossless image to second one, which encodes it in source format
+	first := transforms.Transforms{}
+	first.Resize(200, 0, false, false, false)
+	assert.Nil(t, first.Sharpen(1, 0, 0))
+	first.NoMerge = true
+	second := transforms.Transforms{}
+	second.Resize(100, 0, false, false, false)
+	assert.Nil(t, second.Text("caption", "", 0, "", "bottom-right", 1, 0))
+	second.NoMerge = true
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{first, second})
+
+	assert.Nil(t, err, "image should be adjusted")
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+	assert.Equal(t, "100", res.Headers.Get("x-amz-meta-public-width"))
+}
+
+func TestImageEngine_Process_Animated(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		format      string
+		frame       int
+		contentType string
+	}{
+		{"should keep gif animation", "", -1, "image/gif"},
+		{"should convert gif to animated webp", "webp", -1, "image/webp"},
+		{"should extract single frame", "png", 1, "image/png"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/animated.gif")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/parent.gif", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.Transforms{}
+			trans.Resize(20, 0, false, false, false)
+			if tt.format != "" {
+				assert.Nil(t, trans.Format(tt.format))
+			}
+			if tt.frame >= 0 {
+				assert.Nil(t, trans.Frame(tt.frame))
+			}
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err, "animated image should be processed")
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, tt.contentType, res.Headers.Get("content-type"))
+			assert.Equal(t, "20", res.Headers.Get("x-amz-meta-public-width"))
+		})
+	}
+}
+
+func TestImageEngine_Process_Document(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		page   int
+		dpi    int
+		width  string
+		format string
+	}{
+		{"should rasterize first page", -1, 0, "100", ""},
+		{"should rasterize page with dpi", 1, 144, "200", ""},
+		{"should rasterize page to jpeg", 0, 0, "100", "jpeg"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/document.pdf")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/document.pdf", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.Transforms{}
+			if tt.page >= 0 {
+				assert.Nil(t, trans.Frame(tt.page))
+			}
+			if tt.dpi != 0 {
+				assert.Nil(t, trans.DPI(tt.dpi))
+			}
+			contentType := "image/png"
+			if tt.format != "" {
+				assert.Nil(t, trans.Format(tt.format))
+				contentType = "image/" + tt.format
+			}
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err, "document should be rasterized")
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, contentType, res.Headers.Get("content-type"))
+			assert.Equal(t, tt.width, res.Headers.Get("x-amz-meta-public-width"))
+		})
+	}
+}
+
+func TestImageEngine_Process_DocumentRasterArea(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/document.pdf")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/document.pdf", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.Transforms{}
+	assert.Nil(t, trans.DPI(600))
+	assert.Nil(t, trans.MaxRasterArea(10000))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.NotNil(t, err, "page larger than limit should not be rasterized")
+	assert.Equal(t, 400, res.StatusCode)
+}
+
+func TestImageEngine_Process_FrameSinglePage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		frame      int
+		statusCode int
+	}{
+		{"should return image for first frame", 0, 200},
+		{"should reject frame out of range", 1, 400},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/parent.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.Transforms{}
+			trans.Resize(20, 0, false, false, false)
+			assert.Nil(t, trans.Frame(tt.frame))
+
+			e := NewImageEngine(image)
+			res, _ := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Equal(t, tt.statusCode, res.StatusCode)
+			if tt.statusCode == 200 {
+				assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+			}
+		})
+	}
+}
+
+func TestImageEngine_Process_Info(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	resize := transforms.New()
+	assert.Nil(t, resize.Resize(50, 0, false, false, false))
+	info := transforms.New()
+	info.Info()
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{resize, info})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "application/json", res.Headers.Get("content-type"))
+
+	body, err := res.Body()
+	assert.Nil(t, err)
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(body, &result))
+	assert.Equal(t, float64(50), result["width"])
+	assert.Equal(t, "jpeg", result["format"])
+}
+
+func TestImageEngine_Process_Placeholder(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format string
+		prefix string
+	}{
+		{"blurhash", "L"},
+		{"lqip", "data:image/jpeg;base64,"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.New()
+			assert.Nil(t, trans.Format(tt.format))
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, "text/plain; charset=utf-8", res.Headers.Get("content-type"))
+
+			body, err := res.Body()
+			assert.Nil(t, err)
+			assert.True(t, strings.HasPrefix(string(body), tt.prefix))
+		})
+	}
+}
+
+func TestImageEngine_Process_Palette(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.New()
+	assert.Nil(t, trans.Palette(3))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "application/json", res.Headers.Get("content-type"))
+
+	body, err := res.Body()
+	assert.Nil(t, err)
+	var result struct {
+		Dominant string `json:"dominant"`
+		Colors   []struct {
+			Color      string `json:"color"`
+			Population int    `json:"population"`
+		} `json:"colors"`
+	}
+	assert.Nil(t, json.Unmarshal(body, &result))
+	assert.NotEmpty(t, result.Colors)
+	assert.LessOrEqual(t, len(result.Colors), 3)
+	assert.Equal(t, result.Colors[0].Color, result.Dominant)
+}
+
+func TestImageEngine_Process_ColorProfile(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.New()
+	assert.Nil(t, trans.Resize(50, 0, false, false, false))
+	assert.Nil(t, trans.ColorProfile("srgb", false))
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	// converted image is encoded in source format
+	assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+	assert.Equal(t, "50", res.Headers.Get("x-amz-meta-public-width"))
+
+	buf, err := res.Body()
+	assert.Nil(t, err)
+	meta, err := bimg.Metadata(buf)
+	assert.Nil(t, err)
+	assert.False(t, meta.Profile)
+}
+
+func TestImageEngine_Process_EncoderOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		setup  func(trans *transforms.Transforms) error
+		output string
+	}{
+		{"should encode jpeg without chroma subsampling", func(trans *transforms.Transforms) error {
+			return trans.JpegOptions(true, "off", false, 0)
+		}, "image/jpeg"},
+		{"should encode png with palette", func(trans *transforms.Transforms) error {
+			trans.Format("png")
+			return trans.PngOptions(true, 9, 16)
+		}, "image/png"},
+		{"should encode near lossless webp", func(trans *transforms.Transforms) error {
+			trans.Format("webp")
+			return trans.WebpOptions(true, 6, false)
+		}, "image/webp"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.New()
+			assert.Nil(t, trans.Resize(100, 0, false, false, false))
+			assert.Nil(t, tt.setup(&trans))
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, tt.output, res.Headers.Get("content-type"))
+			assert.Equal(t, "100", res.Headers.Get("x-amz-meta-public-width"))
+		})
+	}
+}
+
+func TestImageEngine_Process_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		maxBytes  int
+		downscale bool
+		format    string
+	}{
+		{"should lower jpeg quality", 3000, false, ""},
+		{"should lower webp quality", 2000, false, "webp"},
+		{"should downscale image", 700, true, ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.New()
+			assert.Nil(t, trans.Resize(100, 0, false, false, false))
+			assert.Nil(t, trans.MaxBytes(tt.maxBytes, tt.downscale))
+			// metadata of source image is larger than budget
+			assert.Nil(t, trans.StripMetadata())
+			if tt.format != "" {
+				assert.Nil(t, trans.Format(tt.format))
+			}
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.NotEmpty(t, res.Headers.Get("x-amz-meta-public-quality"))
+
+			assert.Empty(t, res.Headers.Get("x-amz-meta-public-max-bytes-exceeded"))
+
+			buf, err := res.Body()
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, len(buf), tt.maxBytes)
+		})
+	}
+}
+
+func TestImageEngine_Process_MaxBytesExceeded(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	image := response.New(200, f)
+	mortConfig := config.Config{}
+	mortConfig.Load("testdata/config.yml")
+	obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+	assert.Nil(t, err)
+
+	trans := transforms.New()
+	assert.Nil(t, trans.Resize(100, 0, false, false, false))
+	assert.Nil(t, trans.MaxBytes(100, false))
+	assert.Nil(t, trans.StripMetadata())
+
+	e := NewImageEngine(image)
+	res, err := e.Process(obj, []transforms.Transforms{trans})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "true", res.Headers.Get("x-amz-meta-public-max-bytes-exceeded"))
+	assert.Equal(t, "10", res.Headers.Get("x-amz-meta-public-quality"))
+
+	buf, err := res.Body()
+	assert.Nil(t, err)
+	assert.Greater(t, len(buf), 100)
+}
+
+func TestImageEngine_Process_Mask(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		shape  string
+		radius int
+		format string
+		output string
+	}{
+		{"should switch jpeg to png for circle", "circle", 0, "", "image/png"},
+		{"should keep webp for rounded corners", "rounded", 10, "webp", "image/webp"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.New()
+			assert.Nil(t, trans.Resize(100, 100, false, false, false))
+			assert.Nil(t, trans.Mask(tt.shape, tt.radius, ""))
+			if tt.format != "" {
+				assert.Nil(t, trans.Format(tt.format))
+			}
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, tt.output, res.Headers.Get("content-type"))
+
+			body, err := res.Body()
+			assert.Nil(t, err)
+			meta, err := bimg.Metadata(body)
+			assert.Nil(t, err)
+			assert.True(t, meta.Alpha)
+		})
+	}
+}
+
+func TestImageEngine_Process_Metadata(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []string{"icc", "nogps", "allowlist"} {
+		policy := policy
+		t.Run(policy, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open("testdata/small.jpg")
+			assert.Nil(t, err)
+
+			image := response.New(200, f)
+			mortConfig := config.Config{}
+			mortConfig.Load("testdata/config.yml")
+			obj, err := object.NewFileObjectFromPath("/local/small.jpg", &mortConfig)
+			assert.Nil(t, err)
+
+			trans := transforms.New()
+			assert.Nil(t, trans.Resize(50, 0, false, false, false))
+			assert.Nil(t, trans.Metadata(policy, []string{"Copyright"}))
+
+			e := NewImageEngine(image)
+			res, err := e.Process(obj, []transforms.Transforms{trans})
+
+			assert.Nil(t, err)
+			assert.Equal(t, 200, res.StatusCode)
+			assert.Equal(t, "image/jpeg", res.Headers.Get("content-type"))
+			assert.Equal(t, "50", res.Headers.Get("x-amz-meta-public-width"))
+		})
+	}
+}
+
 func TestImageEngine_Process_Rotate(t *testing.T) {
 	t.Parallel()
 
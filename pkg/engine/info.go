@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/h2non/bimg"
+)
+
+// imageInfo is JSON document returned by info operation
+type imageInfo struct {
+	Width       int                    `json:"width"`
+	Height      int                    `json:"height"`
+	Format      string                 `json:"format"`
+	Size        int                    `json:"size"`
+	Pages       int                    `json:"pages"`
+	Orientation int                    `json:"orientation"`
+	Space       string                 `json:"space"`
+	Channels    int                    `json:"channels"`
+	Alpha       bool                   `json:"alpha"`
+	Profile     bool                   `json:"profile"`
+	EXIF        map[string]interface{} `json:"exif,omitempty"`
+	XMP         string                 `json:"xmp,omitempty"`
+}
+
+// describeImage returns metadata of image in buf encoded as JSON
+func describeImage(buf []byte) ([]byte, error) {
+	meta, err := bimg.Metadata(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	xmp, err := imageXMP(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	info := imageInfo{
+		Width:       meta.Size.Width,
+		Height:      meta.Size.Height,
+		Format:      meta.Type,
+		Size:        len(buf),
+		Pages:       imagePages(buf),
+		Orientation: meta.Orientation,
+		Space:       meta.Space,
+		Channels:    meta.Channels,
+		Alpha:       meta.Alpha,
+		Profile:     meta.Profile,
+		EXIF:        exifFields(meta.EXIF),
+		XMP:         xmp,
+	}
+
+	return json.Marshal(info)
+}
+
+// exifFields returns EXIF fields which are present in image
+// maker note is skipped as it is vendor specific binary data
+func exifFields(exif bimg.EXIF) map[string]interface{} {
+	fields := make(map[string]interface{})
+	v := reflect.ValueOf(exif)
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "MakerNote" || v.Field(i).IsZero() {
+			continue
+		}
+		fields[name] = v.Field(i).Interface()
+	}
+
+	return fields
+}
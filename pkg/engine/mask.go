@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"strconv"
+
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
+)
+
+// applyMask removes parts of image outside of mask shape
+func applyMask(buf []byte, mask transforms.MaskOptions) ([]byte, error) {
+	maskBuf := mask.Image
+	if mask.Shape != "image" {
+		size, err := bimg.Size(buf)
+		if err != nil {
+			return nil, err
+		}
+		maskBuf = shapeMask(mask.Shape, mask.Radius, size.Width, size.Height)
+	}
+
+	return maskImage(buf, maskBuf)
+}
+
+// shapeMask returns SVG image with given shape drawn on transparent canvas of given size
+// circle is inscribed in canvas, rounded is rectangle with corners of given radius
+func shapeMask(shape string, radius, width, height int) []byte {
+	w := strconv.Itoa(width)
+	h := strconv.Itoa(height)
+
+	var body string
+	if shape == "circle" {
+		r := width
+		if height < r {
+			r = height
+		}
+		body = `<circle cx="` + strconv.FormatFloat(float64(width)/2, 'f', -1, 64) + `" cy="` + strconv.FormatFloat(float64(height)/2, 'f', -1, 64) +
+			`" r="` + strconv.FormatFloat(float64(r)/2, 'f', -1, 64) + `" fill="#fff"/>`
+	} else {
+		r := strconv.Itoa(radius)
+		body = `<rect width="` + w + `" height="` + h + `" rx="` + r + `" ry="` + r + `" fill="#fff"/>`
+	}
+
+	return []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="` + w + `" height="` + h + `">` + body + `</svg>`)
+}
@@ -0,0 +1,16 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShapeMask(t *testing.T) {
+	circle := string(shapeMask("circle", 0, 100, 50))
+	assert.Contains(t, circle, `width="100" height="50"`)
+	assert.Contains(t, circle, `<circle cx="50" cy="25" r="25"`)
+
+	rounded := string(shapeMask("rounded", 8, 100, 50))
+	assert.Contains(t, rounded, `rx="8" ry="8"`)
+}
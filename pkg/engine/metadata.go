@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
+)
+
+// uploadSuffixes lossless libvips savers for uploaded formats which can't be filtered without decoding
+var uploadSuffixes = map[string]string{
+	"avif": ".avif[lossless=true]",
+	"heif": ".heic[lossless=true]",
+}
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	jpegXMPExt     = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	jpegIPTCHeader = []byte("Photoshop 3.0\x00")
+	jpegICCHeader  = []byte("ICC_PROFILE\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+	pngXMPKeyword  = []byte("XML:com.adobe.xmp\x00")
+	// ImageMagick stores profiles in text chunks with keyword "Raw profile type <name>"
+	pngRawProfile = []byte("Raw profile type ")
+)
+
+var errInvalidImage = errors.New("invalid image data")
+
+// metadataFilter decides which metadata of image are kept, rules are the same as for processed images (mort_drop_field)
+type metadataFilter struct {
+	policy string
+	tags   map[string]bool
+}
+
+func newMetadataFilter(policy string, tags []string) (metadataFilter, error) {
+	if _, ok := metadataModes[policy]; !ok {
+		return metadataFilter{}, errors.New("unknown metadata policy " + policy)
+	}
+
+	f := metadataFilter{policy: policy, tags: make(map[string]bool, len(tags))}
+	for _, tag := range tags {
+		f.tags[tag] = true
+	}
+
+	return f, nil
+}
+
+// keepTag returns true when EXIF tag of given IFD is kept
+func (f metadataFilter) keepTag(ifd int, tag uint16) bool {
+	switch f.policy {
+	case "nogps":
+		return ifd != exifIFDGPS
+	case "allowlist":
+		name := exifTagName(ifd, tag)
+		return name != "" && f.tags[name]
+	}
+
+	return false
+}
+
+// exif returns filtered EXIF data, nil means that EXIF should be removed
+func (f metadataFilter) exif(data []byte) []byte {
+	if f.policy == "strip" || f.policy == "icc" {
+		return nil
+	}
+
+	// EXIF which can't be parsed is removed, otherwise it could leak not allowed tags
+	filtered, err := filterExif(data, f.keepTag)
+	if err != nil {
+		return nil
+	}
+
+	return filtered
+}
+
+// XMP is removed also by nogps policy as it often contains GPS position
+func (f metadataFilter) keepXMP() bool {
+	return f.policy == "allowlist" && f.tags["xmp"]
+}
+
+func (f metadataFilter) keepIPTC() bool {
+	return f.policy == "nogps" || (f.policy == "allowlist" && f.tags["iptc"])
+}
+
+func (f metadataFilter) keepICC() bool {
+	return f.policy != "strip"
+}
+
+// FilterUploadMetadata removes metadata of uploaded image which are not allowed by policy
+// JPEG, PNG and WebP are filtered without decoding, AVIF and HEIF are saved again losslessly
+// it returns false when image was not changed (keep policy or format which can't be filtered)
+func FilterUploadMetadata(buf []byte, policy string, tags []string) ([]byte, bool, error) {
+	if policy == "" || policy == "keep" {
+		return buf, false, nil
+	}
+
+	format := bimg.DetermineImageTypeName(buf)
+	if suffix, ok := uploadSuffixes[format]; ok {
+		buf, err := filterMetadata(buf, transforms.MetadataOptions{Policy: policy, Tags: tags}, suffix)
+		return buf, err == nil, err
+	}
+
+	f, err := newMetadataFilter(policy, tags)
+	if err != nil {
+		return buf, false, err
+	}
+
+	var out []byte
+	switch format {
+	case "jpeg":
+		out, err = filterJPEGMetadata(buf, f)
+	case "png":
+		out, err = filterPNGMetadata(buf, f)
+	case "webp":
+		out, err = filterWebPMetadata(buf, f)
+	default:
+		return buf, false, nil
+	}
+
+	if err != nil {
+		return buf, false, err
+	}
+
+	return out, !bytes.Equal(out, buf), nil
+}
+
+// filterJPEGMetadata filters APP segments of JPEG, entropy coded data is copied as is
+func filterJPEGMetadata(buf []byte, f metadataFilter) ([]byte, error) {
+	if len(buf) < 4 || buf[0] != 0xFF || buf[1] != 0xD8 {
+		return nil, errInvalidImage
+	}
+
+	out := make([]byte, 0, len(buf))
+	out = append(out, buf[:2]...)
+	pos := 2
+	for {
+		if pos+4 > len(buf) || buf[pos] != 0xFF {
+			return nil, errInvalidImage
+		}
+
+		marker := buf[pos+1]
+		if marker == 0xFF {
+			// fill byte
+			pos++
+			continue
+		}
+
+		// start of scan, the rest of file is image data
+		if marker == 0xDA {
+			return append(out, buf[pos:]...), nil
+		}
+
+		end := pos + 2 + int(binary.BigEndian.Uint16(buf[pos+2:]))
+		if end > len(buf) || end < pos+4 {
+			return nil, errInvalidImage
+		}
+
+		segment := buf[pos:end]
+		payload := segment[4:]
+		switch {
+		case marker == 0xE1 && bytes.HasPrefix(payload, jpegExifHeader):
+			exif := f.exif(payload[len(jpegExifHeader):])
+			if exif != nil {
+				out = append(out, segment[:4+len(jpegExifHeader)]...)
+				out = append(out, exif...)
+			}
+		case marker == 0xE1 && (bytes.HasPrefix(payload, jpegXMPHeader) || bytes.HasPrefix(payload, jpegXMPExt)):
+			if f.keepXMP() {
+				out = append(out, segment...)
+			}
+		case marker == 0xED && bytes.HasPrefix(payload, jpegIPTCHeader):
+			if f.keepIPTC() {
+				out = append(out, segment...)
+			}
+		case marker == 0xE2 && bytes.HasPrefix(payload, jpegICCHeader):
+			if f.keepICC() {
+				out = append(out, segment...)
+			}
+		default:
+			out = append(out, segment...)
+		}
+
+		pos = end
+	}
+}
+
+// keepPNGText returns true when text chunk (tEXt, zTXt or iTXt) isn't metadata removed by policy
+func (f metadataFilter) keepPNGText(data []byte) bool {
+	if bytes.HasPrefix(data, pngXMPKeyword) {
+		return f.keepXMP()
+	}
+
+	if !bytes.HasPrefix(data, pngRawProfile) {
+		return true
+	}
+
+	keyword := data[len(pngRawProfile):]
+	if end := bytes.IndexByte(keyword, 0); end != -1 {
+		keyword = keyword[:end]
+	}
+
+	switch strings.ToLower(string(keyword)) {
+	case "exif", "app1":
+		// hex encoded EXIF isn't filtered by tags
+		return false
+	case "xmp":
+		return f.keepXMP()
+	case "iptc", "8bim":
+		return f.keepIPTC()
+	case "icc", "icm":
+		return f.keepICC()
+	}
+
+	return true
+}
+
+// filterPNGMetadata filters eXIf, iCCP and text chunks with XMP or raw profiles of PNG
+func filterPNGMetadata(buf []byte, f metadataFilter) ([]byte, error) {
+	if !bytes.HasPrefix(buf, pngSignature) {
+		return nil, errInvalidImage
+	}
+
+	out := make([]byte, 0, len(buf))
+	out = append(out, pngSignature...)
+	pos := len(pngSignature)
+	for pos < len(buf) {
+		if pos+12 > len(buf) {
+			return nil, errInvalidImage
+		}
+
+		length := int(binary.BigEndian.Uint32(buf[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(buf) || end < pos {
+			return nil, errInvalidImage
+		}
+
+		chunk := buf[pos:end]
+		data := chunk[8 : 8+length]
+		switch string(chunk[4:8]) {
+		case "eXIf":
+			if exif := f.exif(data); exif != nil {
+				out = appendPNGChunk(out, "eXIf", exif)
+			}
+		case "tEXt", "zTXt", "iTXt":
+			if f.keepPNGText(data) {
+				out = append(out, chunk...)
+			}
+		case "iCCP":
+			if f.keepICC() {
+				out = append(out, chunk...)
+			}
+		default:
+			out = append(out, chunk...)
+		}
+
+		pos = end
+	}
+
+	return out, nil
+}
+
+func appendPNGChunk(out []byte, kind string, data []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	start := len(out)
+	out = append(out, kind...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
+
+// VP8X flags of metadata chunks
+const (
+	webpFlagICC  = 0x20
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// filterWebPMetadata filters EXIF, XMP and ICCP chunks of WebP and updates VP8X flags
+func filterWebPMetadata(buf []byte, f metadataFilter) ([]byte, error) {
+	if len(buf) < 12 || string(buf[:4]) != "RIFF" || string(buf[8:12]) != "WEBP" {
+		return nil, errInvalidImage
+	}
+
+	out := make([]byte, 0, len(buf))
+	out = append(out, buf[:12]...)
+	flags := -1
+	var removed byte
+	pos := 12
+	for pos < len(buf) {
+		if pos+8 > len(buf) {
+			return nil, errInvalidImage
+		}
+
+		length := int(binary.LittleEndian.Uint32(buf[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(buf) || end < pos {
+			return nil, errInvalidImage
+		}
+		if length%2 == 1 && end < len(buf) {
+			end++
+		}
+
+		chunk := buf[pos:end]
+		data := chunk[8 : 8+length]
+		switch string(chunk[:4]) {
+		case "VP8X":
+			out = append(out, chunk...)
+			if length > 0 {
+				flags = len(out) - len(chunk) + 8
+			}
+		case "EXIF":
+			// some encoders store EXIF with JPEG header
+			prefix := 0
+			if bytes.HasPrefix(data, jpegExifHeader) {
+				prefix = len(jpegExifHeader)
+			}
+
+			if exif := f.exif(data[prefix:]); exif != nil {
+				out = appendWebPChunk(out, "EXIF", append(append([]byte{}, data[:prefix]...), exif...))
+			} else {
+				removed |= webpFlagEXIF
+			}
+		case "XMP ":
+			if f.keepXMP() {
+				out = append(out, chunk...)
+			} else {
+				removed |= webpFlagXMP
+			}
+		case "ICCP":
+			if f.keepICC() {
+				out = append(out, chunk...)
+			} else {
+				removed |= webpFlagICC
+			}
+		default:
+			out = append(out, chunk...)
+		}
+
+		pos = end
+	}
+
+	if flags != -1 {
+		out[flags] &^= removed
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out, nil
+}
+
+func appendWebPChunk(out []byte, kind string, data []byte) []byte {
+	out = append(out, kind...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+
+	return out
+}
@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterUploadMetadata_Unchanged(t *testing.T) {
+	image, err := os.ReadFile("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	buf, changed, err := FilterUploadMetadata(image, "keep", nil)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, image, buf)
+
+	// only images are filtered
+	text := []byte("not an image")
+	buf, changed, err = FilterUploadMetadata(text, "strip", nil)
+	assert.Nil(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, text, buf)
+}
+
+func TestFilterUploadMetadata(t *testing.T) {
+	image, err := os.ReadFile("testdata/small.jpg")
+	assert.Nil(t, err)
+
+	buf, changed, err := FilterUploadMetadata(image, "icc", nil)
+	assert.Nil(t, err)
+	assert.True(t, changed)
+	assert.NotEmpty(t, buf)
+}
+
+// testExif builds little endian EXIF with Artist and Make in IFD0 and GPSLatitude in GPS IFD
+func testExif() []byte {
+	le := binary.LittleEndian
+	buf := make([]byte, 110)
+	copy(buf, "II*\x00")
+	le.PutUint32(buf[4:], 8)
+
+	// IFD0 at 8: 3 entries, next IFD 0
+	le.PutUint16(buf[8:], 3)
+	entry := func(offset int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(buf[offset:], tag)
+		le.PutUint16(buf[offset+2:], typ)
+		le.PutUint32(buf[offset+4:], count)
+		le.PutUint32(buf[offset+8:], value)
+	}
+	entry(10, 0x010F, 2, 4, le.Uint32([]byte("Cam\x00")))
+	entry(22, 0x013B, 2, 8, 60)
+	entry(34, 0x8825, 4, 1, 68)
+	copy(buf[60:], "Artist!\x00")
+
+	// GPS IFD at 68: 1 entry, value at 86
+	le.PutUint16(buf[68:], 1)
+	entry(70, 0x0002, 5, 3, 86)
+	for i := 86; i < len(buf); i++ {
+		buf[i] = 0xAA
+	}
+
+	return buf
+}
+
+func TestFilterExif(t *testing.T) {
+	exif := testExif()
+
+	f, err := newMetadataFilter("nogps", nil)
+	assert.Nil(t, err)
+	buf, err := filterExif(exif, f.keepTag)
+	assert.Nil(t, err)
+	assert.Len(t, buf, len(exif))
+	assert.Equal(t, uint16(2), binary.LittleEndian.Uint16(buf[8:]), "GPS pointer should be removed")
+	assert.Equal(t, make([]byte, len(exif)-68), buf[68:], "GPS IFD and its values should be cleared")
+	assert.Equal(t, "Artist!\x00", string(buf[60:68]))
+	assert.Equal(t, byte(0xAA), exif[86], "input should not be changed")
+
+	f, err = newMetadataFilter("allowlist", []string{"Artist"})
+	assert.Nil(t, err)
+	buf, err = filterExif(exif, f.keepTag)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(buf[8:]))
+	assert.Equal(t, uint16(0x013B), binary.LittleEndian.Uint16(buf[10:]))
+	assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(buf[22:]), "next IFD should follow kept entries")
+
+	f, err = newMetadataFilter("allowlist", []string{"GPSVersionID"})
+	assert.Nil(t, err)
+	buf, err = filterExif(exif, f.keepTag)
+	assert.Nil(t, err)
+	assert.Nil(t, buf, "exif without tags should be removed")
+
+	_, err = filterExif([]byte("not exif"), f.keepTag)
+	assert.NotNil(t, err)
+}
+
+func TestFilterExifThumbnailOnly(t *testing.T) {
+	le := binary.LittleEndian
+	exif := testExif()
+	// IFD1 at the end of data with Compression tag
+	le.PutUint32(exif[46:], 110)
+	exif = append(exif, make([]byte, 18)...)
+	le.PutUint16(exif[110:], 1)
+	le.PutUint16(exif[112:], 0x0103)
+	le.PutUint16(exif[114:], 3)
+	le.PutUint32(exif[116:], 1)
+	le.PutUint32(exif[120:], 6)
+
+	f, err := newMetadataFilter("allowlist", []string{"Compression"})
+	assert.Nil(t, err)
+	buf, err := filterExif(exif, f.keepTag)
+	assert.Nil(t, err)
+	assert.Len(t, buf, len(exif))
+	assert.Equal(t, uint16(0), le.Uint16(buf[8:]))
+	assert.Equal(t, uint32(110), le.Uint32(buf[10:]), "IFD1 should be still linked from empty IFD0")
+	assert.Equal(t, uint16(0x0103), le.Uint16(buf[112:]))
+}
+
+// jpegSegments returns markers of JPEG segments before start of scan and image data starting with it
+func jpegSegments(buf []byte) ([]byte, []byte) {
+	var markers []byte
+	pos := 2
+	for ; buf[pos+1] != 0xDA; pos += 2 + int(binary.BigEndian.Uint16(buf[pos+2:])) {
+		markers = append(markers, buf[pos+1])
+	}
+
+	return markers, buf[pos:]
+}
+
+func TestFilterJPEGMetadata(t *testing.T) {
+	image, err := os.ReadFile("testdata/small.jpg")
+	assert.Nil(t, err)
+	_, scan := jpegSegments(image)
+
+	tests := []struct {
+		policy   string
+		tags     []string
+		segments []byte
+	}{
+		{"strip", nil, []byte{0xE0, 0xDB, 0xDB, 0xC0, 0xC4, 0xC4, 0xC4, 0xC4}},
+		{"nogps", nil, []byte{0xE0, 0xE1, 0xED, 0xDB, 0xDB, 0xC0, 0xC4, 0xC4, 0xC4, 0xC4}},
+		{"allowlist", []string{"xmp"}, []byte{0xE0, 0xE1, 0xDB, 0xDB, 0xC0, 0xC4, 0xC4, 0xC4, 0xC4}},
+		{"allowlist", []string{"Make", "iptc"}, []byte{0xE0, 0xE1, 0xED, 0xDB, 0xDB, 0xC0, 0xC4, 0xC4, 0xC4, 0xC4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			f, err := newMetadataFilter(tt.policy, tt.tags)
+			assert.Nil(t, err)
+
+			buf, err := filterJPEGMetadata(image, f)
+			assert.Nil(t, err)
+			segments, data := jpegSegments(buf)
+			assert.Equal(t, tt.segments, segments)
+			assert.Equal(t, scan, data, "image data should be copied without encoding")
+		})
+	}
+
+	_, err = filterJPEGMetadata([]byte{0xFF, 0xD8, 0x00}, metadataFilter{policy: "strip"})
+	assert.NotNil(t, err)
+}
+
+func TestFilterPNGMetadata(t *testing.T) {
+	chunk := func(kind string, data []byte) []byte {
+		return appendPNGChunk(nil, kind, data)
+	}
+
+	image := append([]byte{}, pngSignature...)
+	image = append(image, chunk("IHDR", make([]byte, 13))...)
+	image = append(image, chunk("iCCP", []byte("icc"))...)
+	image = append(image, chunk("eXIf", testExif())...)
+	image = append(image, chunk("iTXt", append(append([]byte{}, pngXMPKeyword...), "xmp"...))...)
+	image = append(image, chunk("zTXt", []byte("Raw profile type exif\x00\x00data"))...)
+	image = append(image, chunk("tEXt", []byte("Raw profile type xmp\x00data"))...)
+	image = append(image, chunk("tEXt", []byte("Raw profile type iptc\x00data"))...)
+	image = append(image, chunk("tEXt", []byte("Comment\x00mort"))...)
+	image = append(image, chunk("IDAT", []byte("data"))...)
+	image = append(image, chunk("IEND", nil)...)
+
+	f, err := newMetadataFilter("icc", nil)
+	assert.Nil(t, err)
+	buf, err := filterPNGMetadata(image, f)
+	assert.Nil(t, err)
+
+	expected := append([]byte{}, pngSignature...)
+	expected = append(expected, chunk("IHDR", make([]byte, 13))...)
+	expected = append(expected, chunk("iCCP", []byte("icc"))...)
+	expected = append(expected, chunk("tEXt", []byte("Comment\x00mort"))...)
+	expected = append(expected, chunk("IDAT", []byte("data"))...)
+	expected = append(expected, chunk("IEND", nil)...)
+	assert.Equal(t, expected, buf)
+
+	// XMP and raw EXIF profile can contain GPS position
+	f, err = newMetadataFilter("nogps", nil)
+	assert.Nil(t, err)
+	buf, err = filterPNGMetadata(image, f)
+	assert.Nil(t, err)
+
+	expected = append([]byte{}, pngSignature...)
+	expected = append(expected, chunk("IHDR", make([]byte, 13))...)
+	expected = append(expected, chunk("iCCP", []byte("icc"))...)
+	exif, err := filterExif(testExif(), f.keepTag)
+	assert.Nil(t, err)
+	expected = append(expected, chunk("eXIf", exif)...)
+	expected = append(expected, chunk("tEXt", []byte("Raw profile type iptc\x00data"))...)
+	expected = append(expected, chunk("tEXt", []byte("Comment\x00mort"))...)
+	expected = append(expected, chunk("IDAT", []byte("data"))...)
+	expected = append(expected, chunk("IEND", nil)...)
+	assert.Equal(t, expected, buf)
+}
+
+func TestFilterWebPMetadata(t *testing.T) {
+	chunk := func(kind string, data []byte) []byte {
+		return appendWebPChunk(nil, kind, data)
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagICC | webpFlagEXIF | webpFlagXMP
+	image := []byte("RIFF\x00\x00\x00\x00WEBP")
+	image = append(image, chunk("VP8X", vp8x)...)
+	image = append(image, chunk("ICCP", []byte("icc"))...)
+	image = append(image, chunk("VP8 ", []byte("data"))...)
+	image = append(image, chunk("EXIF", testExif())...)
+	image = append(image, chunk("XMP ", []byte("xmp"))...)
+	binary.LittleEndian.PutUint32(image[4:], uint32(len(image)-8))
+
+	f, err := newMetadataFilter("strip", nil)
+	assert.Nil(t, err)
+	buf, err := filterWebPMetadata(image, f)
+	assert.Nil(t, err)
+
+	vp8x[0] = 0
+	expected := []byte("RIFF\x00\x00\x00\x00WEBP")
+	expected = append(expected, chunk("VP8X", vp8x)...)
+	expected = append(expected, chunk("VP8 ", []byte("data"))...)
+	binary.LittleEndian.PutUint32(expected[4:], uint32(len(expected)-8))
+	assert.Equal(t, expected, buf)
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"image/png"
+	"sort"
+
+	"github.com/h2non/bimg"
+)
+
+// paletteSize longer side of image used for computing palette
+const paletteSize = 64
+
+// paletteColor is single color of palette with number of pixels that it represents
+type paletteColor struct {
+	Color      string `json:"color"`
+	Population int    `json:"population"`
+}
+
+// imagePalette is JSON document returned by palette operation
+type imagePalette struct {
+	Dominant string         `json:"dominant"`
+	Colors   []paletteColor `json:"colors"`
+}
+
+// colorBox is set of pixels which is split by median cut
+type colorBox []color.NRGBA
+
+// channel returns value of pixel in given channel (0 - red, 1 - green, 2 - blue)
+func channel(c color.NRGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+// widestChannel returns channel with largest range of values and its range
+func (b colorBox) widestChannel() (int, int) {
+	widest, widestRange := 0, -1
+	for ch := 0; ch < 3; ch++ {
+		min, max := 255, 0
+		for _, c := range b {
+			v := int(channel(c, ch))
+			if v < min {
+				min = v
+			}
+			if v > max {
+				max = v
+			}
+		}
+
+		if max-min > widestRange {
+			widest, widestRange = ch, max-min
+		}
+	}
+
+	return widest, widestRange
+}
+
+// average returns mean color of box
+func (b colorBox) average() string {
+	var r, g, bl int
+	for _, c := range b {
+		r += int(c.R)
+		g += int(c.G)
+		bl += int(c.B)
+	}
+
+	n := len(b)
+	return fmt.Sprintf("#%02x%02x%02x", (r+n/2)/n, (g+n/2)/n, (bl+n/2)/n)
+}
+
+// medianCut splits pixels into at most colors boxes, box with most pixels is split first
+func medianCut(pixels colorBox, colors int) []colorBox {
+	boxes := []colorBox{pixels}
+	for len(boxes) < colors {
+		idx := -1
+		for i, box := range boxes {
+			if _, r := box.widestChannel(); r > 0 && len(box) > 1 && (idx == -1 || len(box) > len(boxes[idx])) {
+				idx = i
+			}
+		}
+
+		// all boxes contain single color
+		if idx == -1 {
+			break
+		}
+
+		box := boxes[idx]
+		ch, _ := box.widestChannel()
+		sort.Slice(box, func(i, j int) bool {
+			return channel(box[i], ch) < channel(box[j], ch)
+		})
+
+		median := len(box) / 2
+		boxes[idx] = box[:median]
+		boxes = append(boxes, box[median:])
+	}
+
+	return boxes
+}
+
+// describePalette returns dominant color and palette of image in buf encoded as JSON
+func describePalette(buf []byte, colors int) ([]byte, error) {
+	small, err := downscale(buf, paletteSize, bimg.Options{Type: bimg.PNG, Interpretation: bimg.InterpretationSRGB})
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(small))
+	if err != nil {
+		return nil, err
+	}
+
+	// transparent pixels are not visible so they are skipped
+	bounds := img.Bounds()
+	pixels := make(colorBox, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A >= 128 {
+				pixels = append(pixels, c)
+			}
+		}
+	}
+
+	result := imagePalette{Colors: []paletteColor{}}
+	if len(pixels) > 0 {
+		boxes := medianCut(pixels, colors)
+		sort.SliceStable(boxes, func(i, j int) bool {
+			return len(boxes[i]) > len(boxes[j])
+		})
+
+		for _, box := range boxes {
+			result.Colors = append(result.Colors, paletteColor{Color: box.average(), Population: len(box)})
+		}
+		result.Dominant = result.Colors[0].Color
+	}
+
+	return json.Marshal(result)
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMedianCut(t *testing.T) {
+	pixels := colorBox{}
+	for i := 0; i < 6; i++ {
+		pixels = append(pixels, color.NRGBA{R: 255, A: 255})
+	}
+	for i := 0; i < 2; i++ {
+		pixels = append(pixels, color.NRGBA{B: 255, A: 255})
+	}
+
+	boxes := medianCut(pixels, 4)
+	total := 0
+	for _, box := range boxes {
+		total += len(box)
+	}
+	assert.Equal(t, 8, total)
+	assert.LessOrEqual(t, len(boxes), 4)
+
+	// single color can't be split
+	boxes = medianCut(colorBox{{R: 10, A: 255}, {R: 10, A: 255}}, 3)
+	assert.Len(t, boxes, 1)
+	assert.Equal(t, "#0a0000", boxes[0].average())
+}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
+)
+
+const (
+	// blurHashSize longer side of image used for computing BlurHash
+	blurHashSize = 32
+	// blurHashComponents number of components along longer side of image, shorter side uses 3
+	blurHashComponents = 4
+	// lqipSize longer side of LQIP image
+	lqipSize = 16
+	// defaultLqipQuality quality of LQIP image when transform doesn't set it
+	defaultLqipQuality = 40
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// isPlaceholder returns true when format is text placeholder instead of image
+func isPlaceholder(format string) bool {
+	return format == "blurhash" || format == "lqip"
+}
+
+// downscale resize image so its longer side is equal to size, image is never enlarged
+func downscale(buf []byte, size int, opts bimg.Options) ([]byte, error) {
+	meta, err := bimg.Metadata(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Size.Width >= meta.Size.Height {
+		opts.Width = size
+	} else {
+		opts.Height = size
+	}
+
+	return bimg.Resize(buf, opts)
+}
+
+// encodeBlurHash returns BlurHash (https://blurha.sh) of image
+func encodeBlurHash(buf []byte) ([]byte, error) {
+	// transparent pixels are flattened on white background
+	small, err := downscale(buf, blurHashSize, bimg.Options{
+		Type:           bimg.PNG,
+		Background:     bimg.Color{R: 255, G: 255, B: 255},
+		Interpretation: bimg.InterpretationSRGB,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(small))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	xComponents, yComponents := blurHashComponents, 3
+	if bounds.Dy() > bounds.Dx() {
+		xComponents, yComponents = 3, blurHashComponents
+	}
+
+	return []byte(blurHash(img, xComponents, yComponents)), nil
+}
+
+// encodeLQIP returns tiny image encoded as data URI
+func encodeLQIP(buf []byte, enc transforms.Encoder) ([]byte, error) {
+	quality := enc.Quality
+	if quality == 0 {
+		quality = defaultLqipQuality
+	}
+
+	meta, err := bimg.Metadata(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// transparency is kept only by png
+	opts := bimg.Options{Type: bimg.JPEG, Quality: quality, StripMetadata: true}
+	mime := "image/jpeg"
+	if meta.Alpha {
+		opts = bimg.Options{Type: bimg.PNG, StripMetadata: true}
+		mime = "image/png"
+	}
+
+	small, err := downscale(buf, lqipSize, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte("data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(small)), nil
+}
+
+// blurHash computes BlurHash of image with given number of components
+func blurHash(img image.Image, xComponents, yComponents int) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// pixels are converted to linear RGB once, they are used for each component
+	pixels := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			pixels[y*width+x] = [3]float64{sRGBToLinear(r >> 8), sRGBToLinear(g >> 8), sRGBToLinear(b >> 8)}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation * math.Cos(math.Pi*float64(i*x)/float64(width)) * math.Cos(math.Pi*float64(j*y)/float64(height))
+					p := pixels[y*width+x]
+					factor[0] += basis * p[0]
+					factor[1] += basis * p[1]
+					factor[2] += basis * p[2]
+				}
+			}
+
+			scale := 1 / float64(width*height)
+			factors = append(factors, [3]float64{factor[0] * scale, factor[1] * scale, factor[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(base83(xComponents-1+(yComponents-1)*9, 1))
+
+	maximumValue := 1.0
+	if len(factors) > 1 {
+		actualMaximum := 0.0
+		for _, f := range factors[1:] {
+			actualMaximum = math.Max(actualMaximum, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		hash.WriteString(base83(quantisedMaximum, 1))
+	} else {
+		hash.WriteString(base83(0, 1))
+	}
+
+	dc := factors[0]
+	hash.WriteString(base83(linearTosRGB(dc[0])<<16+linearTosRGB(dc[1])<<8+linearTosRGB(dc[2]), 4))
+
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(base83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+
+	return hash.String()
+}
+
+func sRGBToLinear(value uint32) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearTosRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
+
+func base83(value, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		result[i-1] = base83Chars[digit]
+	}
+	return string(result)
+}
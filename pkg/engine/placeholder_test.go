@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlurHash(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	// size flag, maximum AC value, DC component (average color) and 11 AC components
+	hash := blurHash(img, 4, 3)
+	assert.Len(t, hash, 28)
+	assert.Equal(t, "L", hash[:1])
+	assert.Equal(t, base83(255<<16, 4), hash[2:6])
+
+	assert.Equal(t, 6+2*2, len(blurHash(img, 3, 1)))
+}
+
+func TestBase83(t *testing.T) {
+	assert.Equal(t, "0", base83(0, 1))
+	assert.Equal(t, "~", base83(82, 1))
+	assert.Equal(t, "10", base83(83, 2))
+}
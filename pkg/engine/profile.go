@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"errors"
+	"os"
+
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// vipsProfiles maps names of built-in profiles to names used by libvips
+var vipsProfiles = map[string]string{
+	"srgb": "sRGB",
+	"p3":   "p3",
+}
+
+// applyColorProfile converts colors of image to built-in or supplied ICC profile
+func applyColorProfile(buf []byte, opts transforms.ColorProfileOptions) ([]byte, error) {
+	if len(opts.Profile) == 0 {
+		profile, ok := vipsProfiles[opts.Target]
+		if !ok {
+			return nil, errors.New("unknown color profile " + opts.Target)
+		}
+		return iccTransform(buf, profile)
+	}
+
+	// libvips loads profiles only from files
+	f, err := os.CreateTemp("", "mort-*.icc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(opts.Profile)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return iccTransform(buf, f.Name())
+}
@@ -0,0 +1,888 @@
+package engine
+
+/*
+#cgo pkg-config: vips
+#include <stdlib.h>
+#include <string.h>
+#include <vips/vips.h>
+
+static int
+mort_jxlsave(void *buf, size_t len, void **out, size_t *out_len, int quality, int effort, int lossless, int strip) {
+#if (VIPS_MAJOR_VERSION > 8 || (VIPS_MAJOR_VERSION == 8 && VIPS_MINOR_VERSION >= 11))
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		return -1;
+	}
+
+	int err = vips_jxlsave_buffer(in, out, out_len,
+		"Q", quality,
+		"effort", effort,
+		"lossless", lossless,
+		"strip", strip,
+		NULL
+	);
+	g_object_unref(in);
+	return err;
+#else
+	vips_error("mort", "jxl encoding requires libvips >= 8.11");
+	return -1;
+#endif
+}
+
+static int
+mort_text_overlay(void *buf, size_t len, void **out, size_t *out_len, const char *suffix, const char *text, const char *font,
+	int dpi, double r, double g, double b, double opacity, double xalign, double yalign) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 8);
+	double ink[4] = {r, g, b, 255};
+
+	t[0] = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (t[0] == NULL) {
+		g_object_unref(base);
+		return -1;
+	}
+
+	VipsImage *in = t[0];
+	if (vips_image_guess_interpretation(in) != VIPS_INTERPRETATION_sRGB) {
+		if (vips_colourspace(in, &t[1], VIPS_INTERPRETATION_sRGB, NULL)) {
+			goto error;
+		}
+		in = t[1];
+	}
+
+	// text is rendered as mask, opacity scales mask which is used to blend ink with image
+	if (vips_text(&t[2], text, "font", font, "dpi", dpi, "width", in->Xsize, NULL) ||
+		vips_linear1(t[2], &t[3], opacity, 0.0, NULL) ||
+		vips_cast_uchar(t[3], &t[4], NULL)) {
+		goto error;
+	}
+
+	int left = (int) (xalign * (in->Xsize - t[4]->Xsize));
+	int top = (int) (yalign * (in->Ysize - t[4]->Ysize));
+	if (vips_embed(t[4], &t[5], left > 0 ? left : 0, top > 0 ? top : 0, in->Xsize, in->Ysize, NULL)) {
+		goto error;
+	}
+
+	t[6] = vips_image_new_from_image(in, ink, in->Bands > 4 ? 4 : in->Bands);
+	if (t[6] == NULL ||
+		vips_ifthenelse(t[5], t[6], in, &t[7], "blend", TRUE, NULL) ||
+		vips_image_write_to_buffer(t[7], suffix, out, out_len, NULL)) {
+		goto error;
+	}
+
+	g_object_unref(base);
+	return 0;
+
+error:
+	g_object_unref(base);
+	return -1;
+}
+
+static int
+mort_extend(void *buf, size_t len, void **out, size_t *out_len, int width, int height,
+	double r, double g, double b, double a, double sigma) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 8);
+	double ink[4] = {r, g, b, a};
+
+	t[0] = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (t[0] == NULL) {
+		g_object_unref(base);
+		return -1;
+	}
+
+	VipsImage *in = t[0];
+	if (vips_image_guess_interpretation(in) != VIPS_INTERPRETATION_sRGB) {
+		if (vips_colourspace(in, &t[1], VIPS_INTERPRETATION_sRGB, NULL)) {
+			goto error;
+		}
+		in = t[1];
+	}
+
+	// image is only downsized to fit in canvas
+	if (vips_thumbnail_image(in, &t[2], width, "height", height, "size", VIPS_SIZE_DOWN, NULL)) {
+		goto error;
+	}
+
+	VipsImage *fitted = t[2];
+	int left = (width - fitted->Xsize) / 2;
+	int top = (height - fitted->Ysize) / 2;
+
+	if (sigma > 0) {
+		// background is blurred copy of image which covers whole canvas
+		if (vips_thumbnail_image(in, &t[3], width, "height", height, "crop", VIPS_INTERESTING_CENTRE, "size", VIPS_SIZE_BOTH, NULL) ||
+			vips_gaussblur(t[3], &t[4], sigma, NULL) ||
+			vips_insert(t[4], fitted, &t[5], left, top, NULL)) {
+			goto error;
+		}
+	} else {
+		if (a < 255 && !vips_image_hasalpha(fitted)) {
+			if (vips_addalpha(fitted, &t[6])) {
+				goto error;
+			}
+			fitted = t[6];
+		}
+
+		VipsArrayDouble *background = vips_array_double_new(ink, fitted->Bands > 4 ? 4 : fitted->Bands);
+		int err = vips_embed(fitted, &t[5], left, top, width, height,
+			"extend", VIPS_EXTEND_BACKGROUND,
+			"background", background,
+			NULL);
+		vips_area_unref(VIPS_AREA(background));
+		if (err) {
+			goto error;
+		}
+	}
+
+	// lossless intermediate image, final encoding is done by bimg
+	if (vips_image_write_to_buffer(t[5], ".png[compression=1]", out, out_len, NULL)) {
+		goto error;
+	}
+
+	g_object_unref(base);
+	return 0;
+
+error:
+	g_object_unref(base);
+	return -1;
+}
+
+static int
+mort_adjust(void *buf, size_t len, void **out, size_t *out_len, const char *suffix,
+	double sigma, double flat, double jagged,
+	int modulate, double brightness, double contrast, double saturation, double hue,
+	int tint, double r, double g, double b, double sr, double sg, double sb) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 16);
+	VipsImage *alpha = NULL;
+
+	t[0] = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (t[0] == NULL) {
+		g_object_unref(base);
+		return -1;
+	}
+
+	VipsImage *in = t[0];
+	if (vips_image_guess_interpretation(in) != VIPS_INTERPRETATION_sRGB) {
+		if (vips_colourspace(in, &t[1], VIPS_INTERPRETATION_sRGB, NULL)) {
+			goto error;
+		}
+		in = t[1];
+	}
+
+	// alpha channel is not adjusted
+	if (vips_image_hasalpha(in)) {
+		if (vips_extract_band(in, &t[2], in->Bands - 1, NULL) ||
+			vips_extract_band(in, &t[3], 0, "n", in->Bands - 1, NULL)) {
+			goto error;
+		}
+		alpha = t[2];
+		in = t[3];
+	}
+
+	if (sigma > 0) {
+		if (vips_sharpen(in, &t[4], "sigma", sigma, "m1", flat, "m2", jagged, NULL)) {
+			goto error;
+		}
+		in = t[4];
+	}
+
+	if (modulate) {
+		// contrast is scaled around middle gray, other values are changed in LCh space
+		double a[3] = {brightness, saturation, 1};
+		double offset[3] = {0, 0, hue};
+		if (vips_linear1(in, &t[5], contrast, 128 * (1 - contrast), NULL) ||
+			vips_colourspace(t[5], &t[6], VIPS_INTERPRETATION_LCH, NULL) ||
+			vips_linear(t[6], &t[7], a, offset, 3, NULL) ||
+			vips_colourspace(t[7], &t[8], VIPS_INTERPRETATION_sRGB, NULL)) {
+			goto error;
+		}
+		in = t[8];
+	}
+
+	if (tint) {
+		// luminance is mapped linearly from shadow to highlight color
+		double a[3] = {(r - sr) / 255, (g - sg) / 255, (b - sb) / 255};
+		double offset[3] = {sr, sg, sb};
+		if (vips_colourspace(in, &t[9], VIPS_INTERPRETATION_B_W, NULL) ||
+			vips_linear(t[9], &t[10], a, offset, 3, NULL) ||
+			vips_copy(t[10], &t[11], "interpretation", VIPS_INTERPRETATION_sRGB, NULL)) {
+			goto error;
+		}
+		in = t[11];
+	}
+
+	if (vips_cast_uchar(in, &t[12], NULL)) {
+		goto error;
+	}
+	in = t[12];
+
+	if (alpha != NULL) {
+		if (vips_bandjoin2(in, alpha, &t[13], NULL)) {
+			goto error;
+		}
+		in = t[13];
+	}
+
+	if (vips_image_write_to_buffer(in, suffix, out, out_len, NULL)) {
+		goto error;
+	}
+
+	g_object_unref(base);
+	return 0;
+
+error:
+	g_object_unref(base);
+	return -1;
+}
+
+static int
+mort_mask(void *buf, size_t len, void *mask_buf, size_t mask_len, void **out, size_t *out_len, const char *suffix) {
+	VipsImage *base = vips_image_new();
+	VipsImage **t = (VipsImage **) vips_object_local_array(VIPS_OBJECT(base), 10);
+
+	t[0] = vips_image_new_from_buffer(buf, len, "", NULL);
+	t[1] = vips_image_new_from_buffer(mask_buf, mask_len, "", NULL);
+	if (t[0] == NULL || t[1] == NULL) {
+		goto error;
+	}
+
+	VipsImage *in = t[0];
+
+	// mask is stretched to size of image, its alpha channel is used when present, otherwise luminance
+	if (vips_thumbnail_image(t[1], &t[2], in->Xsize, "height", in->Ysize, "size", VIPS_SIZE_FORCE, NULL)) {
+		goto error;
+	}
+	if (vips_image_hasalpha(t[2])) {
+		if (vips_extract_band(t[2], &t[3], t[2]->Bands - 1, NULL)) {
+			goto error;
+		}
+	} else if (vips_colourspace(t[2], &t[3], VIPS_INTERPRETATION_B_W, NULL)) {
+		goto error;
+	}
+	if (vips_cast_uchar(t[3], &t[4], NULL)) {
+		goto error;
+	}
+	VipsImage *mask = t[4];
+
+	// existing transparency is multiplied by mask
+	if (vips_image_hasalpha(in)) {
+		if (vips_extract_band(in, &t[5], in->Bands - 1, NULL) ||
+			vips_extract_band(in, &t[6], 0, "n", in->Bands - 1, NULL) ||
+			vips_multiply(t[5], mask, &t[7], NULL) ||
+			vips_linear1(t[7], &t[8], 1.0 / 255, 0, NULL) ||
+			vips_cast_uchar(t[8], &t[9], NULL)) {
+			goto error;
+		}
+		in = t[6];
+		mask = t[9];
+	}
+
+	VipsImage *masked;
+	if (vips_bandjoin2(in, mask, &masked, NULL)) {
+		goto error;
+	}
+
+	int err = vips_image_write_to_buffer(masked, suffix, out, out_len, NULL);
+	g_object_unref(masked);
+	g_object_unref(base);
+	return err;
+
+error:
+	g_object_unref(base);
+	return -1;
+}
+
+enum {
+	MORT_METADATA_STRIP,
+	MORT_METADATA_ICC,
+	MORT_METADATA_NOGPS,
+	MORT_METADATA_ALLOWLIST,
+};
+
+// XMP often contains GPS position, so it is removed also by nogps policy
+static int
+mort_drop_xmp(int mode, const char *allow) {
+	return mode != MORT_METADATA_ALLOWLIST || strstr(allow, ",xmp,") == NULL;
+}
+
+static int
+mort_drop_iptc(int mode, const char *allow) {
+	return mode == MORT_METADATA_NOGPS ? 0 : mode != MORT_METADATA_ALLOWLIST || strstr(allow, ",iptc,") == NULL;
+}
+
+// mort_drop_raw_profile decides about PNG text chunk with ImageMagick "Raw profile type <name>" keyword
+static int
+mort_drop_raw_profile(const char *profile, int mode, const char *allow) {
+	// hex encoded EXIF isn't filtered by tags
+	if (g_ascii_strcasecmp(profile, "exif") == 0 || g_ascii_strcasecmp(profile, "app1") == 0) {
+		return 1;
+	}
+	if (g_ascii_strcasecmp(profile, "xmp") == 0) {
+		return mort_drop_xmp(mode, allow);
+	}
+	if (g_ascii_strcasecmp(profile, "iptc") == 0 || g_ascii_strcasecmp(profile, "8bim") == 0) {
+		return mort_drop_iptc(mode, allow);
+	}
+	if (g_ascii_strcasecmp(profile, "icc") == 0 || g_ascii_strcasecmp(profile, "icm") == 0) {
+		return mode == MORT_METADATA_STRIP;
+	}
+
+	return 0;
+}
+
+// mort_drop_field returns true when metadata field should be removed according to policy
+// allow is comma separated list of kept tags with leading and trailing comma
+static int
+mort_drop_field(const char *name, int mode, const char *allow) {
+	// libvips removes tags from exif blob when their exif-ifd fields are removed
+	if (strcmp(name, VIPS_META_EXIF_NAME) == 0) {
+		return mode == MORT_METADATA_STRIP || mode == MORT_METADATA_ICC;
+	}
+
+	if (g_str_has_prefix(name, "exif-ifd")) {
+		if (mode == MORT_METADATA_NOGPS) {
+			return g_str_has_prefix(name, "exif-ifd3-");
+		}
+		if (mode == MORT_METADATA_ALLOWLIST) {
+			const char *tag = strchr(name + strlen("exif-ifd"), '-');
+			char pattern[256];
+			g_snprintf(pattern, sizeof(pattern), ",%s,", tag != NULL ? tag + 1 : name);
+			return strstr(allow, pattern) == NULL;
+		}
+		return 1;
+	}
+
+	if (strcmp(name, VIPS_META_XMP_NAME) == 0) {
+		return mort_drop_xmp(mode, allow);
+	}
+
+	if (strcmp(name, VIPS_META_IPTC_NAME) == 0) {
+		return mort_drop_iptc(mode, allow);
+	}
+
+	// PNG text chunks are loaded as png-comment-<index>-<keyword>
+	const char *profile;
+	if (g_str_has_prefix(name, "png-comment-") && (profile = strstr(name, "-Raw profile type ")) != NULL) {
+		return mort_drop_raw_profile(profile + strlen("-Raw profile type "), mode, allow);
+	}
+
+	if (strcmp(name, VIPS_META_ICC_NAME) == 0) {
+		return mode == MORT_METADATA_STRIP;
+	}
+
+	return 0;
+}
+
+static int
+mort_metadata(void *buf, size_t len, void **out, size_t *out_len, const char *suffix, int mode, const char *allow) {
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		return -1;
+	}
+
+	// metadata of loaded image can be shared, so fields are removed from copy
+	VipsImage *copy;
+	int err = vips_copy(in, &copy, NULL);
+	g_object_unref(in);
+	if (err) {
+		return -1;
+	}
+
+	gchar **fields = vips_image_get_fields(copy);
+	for (int i = 0; fields[i] != NULL; i++) {
+		if (mort_drop_field(fields[i], mode, allow)) {
+			vips_image_remove(copy, fields[i]);
+		}
+	}
+	g_strfreev(fields);
+
+	err = vips_image_write_to_buffer(copy, suffix, out, out_len, NULL);
+	g_object_unref(copy);
+	return err;
+}
+
+static int
+mort_n_pages(void *buf, size_t len) {
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		vips_error_clear();
+		return 1;
+	}
+
+	int pages = vips_image_get_n_pages(in);
+	g_object_unref(in);
+	return pages;
+}
+
+static int
+mort_frame(void *buf, size_t len, void **out, size_t *out_len, int page, int dpi, guint64 max_area) {
+	VipsImage *in;
+	// dpi is supported only by document loaders
+	if (dpi > 0) {
+		in = vips_image_new_from_buffer(buf, len, "", "page", page, "dpi", (double) dpi, NULL);
+	} else {
+		in = vips_image_new_from_buffer(buf, len, "", "page", page, NULL);
+	}
+	if (in == NULL) {
+		return -1;
+	}
+
+	// loading is lazy, so size is checked before page is rendered
+	if ((guint64) in->Xsize * (guint64) in->Ysize > max_area) {
+		g_object_unref(in);
+		return 1;
+	}
+
+	// lossless intermediate image, final encoding is done by bimg
+	int err = vips_image_write_to_buffer(in, ".png[compression=1]", out, out_len, NULL);
+	g_object_unref(in);
+	return err;
+}
+
+static int
+mort_animated(void *buf, size_t len, void **out, size_t *out_len, const char *suffix,
+	int width, int height, int crop, int enlarge, int force, int max_frames) {
+	char options[32];
+	int pages = mort_n_pages(buf, len);
+	g_snprintf(options, sizeof(options), "n=%d", pages > max_frames ? max_frames : pages);
+
+	VipsSize size = enlarge ? VIPS_SIZE_BOTH : VIPS_SIZE_DOWN;
+	if (force) {
+		size = VIPS_SIZE_FORCE;
+	}
+
+	// thumbnail resizes each frame separately, page height, delays and loop count are kept
+	VipsImage *thumb;
+	if (vips_thumbnail_buffer(buf, len, &thumb, width > 0 ? width : VIPS_MAX_COORD,
+		"height", height > 0 ? height : VIPS_MAX_COORD,
+		"size", size,
+		"crop", crop ? VIPS_INTERESTING_CENTRE : VIPS_INTERESTING_NONE,
+		"option_string", options,
+		NULL)) {
+		return -1;
+	}
+
+	int err = vips_image_write_to_buffer(thumb, suffix, out, out_len, NULL);
+	g_object_unref(thumb);
+	return err;
+}
+
+static int
+mort_xmp(void *buf, size_t len, void **out, size_t *out_len) {
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		return -1;
+	}
+
+	const void *data;
+	size_t size;
+	*out = NULL;
+	*out_len = 0;
+	if (vips_image_get_typeof(in, VIPS_META_XMP_NAME) && !vips_image_get_blob(in, VIPS_META_XMP_NAME, &data, &size) && size > 0) {
+		*out = g_malloc(size);
+		memcpy(*out, data, size);
+		*out_len = size;
+	}
+
+	g_object_unref(in);
+	return 0;
+}
+
+static int
+mort_icc(void *buf, size_t len, void **out, size_t *out_len, const char *profile) {
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		return -1;
+	}
+
+	// embedded profile is used as input, images without it are treated as sRGB or generic CMYK
+	const char *input = vips_image_get_interpretation(in) == VIPS_INTERPRETATION_CMYK ? "cmyk" : "sRGB";
+	VipsImage *converted;
+	int err = vips_icc_transform(in, &converted, profile,
+		"input_profile", input,
+		"embedded", TRUE,
+		"intent", VIPS_INTENT_RELATIVE,
+		NULL);
+	g_object_unref(in);
+	if (err) {
+		return -1;
+	}
+
+	// lossless intermediate image with output profile attached, final encoding is done by bimg
+	err = vips_image_write_to_buffer(converted, ".png[compression=1]", out, out_len, NULL);
+	g_object_unref(converted);
+	return err;
+}
+
+static int
+mort_save(void *buf, size_t len, void **out, size_t *out_len, const char *suffix) {
+	VipsImage *in = vips_image_new_from_buffer(buf, len, "", NULL);
+	if (in == NULL) {
+		return -1;
+	}
+
+	int err = vips_image_write_to_buffer(in, suffix, out, out_len, NULL);
+	g_object_unref(in);
+	return err;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+const (
+	defaultJxlQuality = 75
+	defaultJxlEffort  = 7
+)
+
+// intermediateSuffix libvips save suffix of lossless image passed between operations, it is encoded to output format at the end
+const intermediateSuffix = ".png[compression=1]"
+
+// vipsError returns last libvips error and clears error buffer
+func vipsError() error {
+	msg := C.GoString(C.vips_error_buffer())
+	C.vips_error_clear()
+	if msg == "" {
+		msg = "libvips error"
+	}
+	return errors.New(msg)
+}
+
+// vipsBytes copy libvips allocated buffer to go memory and free it
+func vipsBytes(ptr unsafe.Pointer, length C.size_t) []byte {
+	buf := C.GoBytes(ptr, C.int(length))
+	C.g_free(C.gpointer(ptr))
+	return buf
+}
+
+// encodeJxl encodes image from buf to JPEG XL
+func encodeJxl(buf []byte, enc transforms.Encoder) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	quality := enc.Quality
+	if quality == 0 {
+		quality = defaultJxlQuality
+	}
+
+	effort := enc.Effort
+	if effort == 0 {
+		effort = defaultJxlEffort
+	}
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	err := C.mort_jxlsave(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, C.int(quality), C.int(effort),
+		C.int(boolToInt(enc.Lossless)), C.int(boolToInt(enc.StripMetadata)))
+	if err != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// textMarkup escapes text of overlay, vips_text parses its input as Pango markup
+func textMarkup(text string) string {
+	return html.EscapeString(text)
+}
+
+// drawText draws text overlay on image, result is lossless intermediate image
+func drawText(buf []byte, overlay transforms.TextOverlay) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	cSuffix := C.CString(intermediateSuffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+	cText := C.CString(textMarkup(overlay.Text))
+	defer C.free(unsafe.Pointer(cText))
+	cFont := C.CString(overlay.Font)
+	defer C.free(unsafe.Pointer(cFont))
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	ret := C.mort_text_overlay(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cSuffix, cText, cFont,
+		C.int(overlay.DPI), C.double(overlay.Color.R), C.double(overlay.Color.G), C.double(overlay.Color.B),
+		C.double(overlay.Opacity), C.double(overlay.XAlign), C.double(overlay.YAlign))
+	if ret != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// extendImage places image on canvas with background color or blurred copy of image
+func extendImage(buf []byte, ext transforms.ExtendOptions) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	ret := C.mort_extend(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, C.int(ext.Width), C.int(ext.Height),
+		C.double(ext.Color.R), C.double(ext.Color.G), C.double(ext.Color.B), C.double(ext.Alpha), C.double(ext.Blur))
+	if ret != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// adjustImage applies tonal adjustments on image, result is lossless intermediate image
+func adjustImage(buf []byte, adj transforms.Adjustments) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	cSuffix := C.CString(intermediateSuffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	ret := C.mort_adjust(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cSuffix,
+		C.double(adj.SharpenSigma), C.double(adj.SharpenFlat), C.double(adj.SharpenJagged),
+		C.int(boolToInt(adj.Modulate)), C.double(adj.Brightness), C.double(adj.Contrast), C.double(adj.Saturation), C.double(adj.Hue),
+		C.int(boolToInt(adj.Tint)), C.double(adj.TintColor.R), C.double(adj.TintColor.G), C.double(adj.TintColor.B),
+		C.double(adj.TintShadow.R), C.double(adj.TintShadow.G), C.double(adj.TintShadow.B))
+	if ret != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// maskImage removes parts of image which are transparent (or black) in mask image, result is lossless intermediate image
+func maskImage(buf []byte, maskBuf []byte) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 || len(maskBuf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	cSuffix := C.CString(intermediateSuffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+
+	var ptr unsafe.Pointer
+	var length C.size_t
+	ret := C.mort_mask(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), unsafe.Pointer(&maskBuf[0]), C.size_t(len(maskBuf)), &ptr, &length, cSuffix)
+	if ret != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// metadataModes maps metadata policies to modes of mort_metadata
+var metadataModes = map[string]C.int{
+	"strip":     C.MORT_METADATA_STRIP,
+	"icc":       C.MORT_METADATA_ICC,
+	"nogps":     C.MORT_METADATA_NOGPS,
+	"allowlist": C.MORT_METADATA_ALLOWLIST,
+}
+
+// filterMetadata removes metadata of image which are not allowed by policy, result is saved using given libvips suffix
+func filterMetadata(buf []byte, opts transforms.MetadataOptions, suffix string) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	mode, ok := metadataModes[opts.Policy]
+	if !ok {
+		return nil, errors.New("unknown metadata policy " + opts.Policy)
+	}
+
+	cSuffix := C.CString(suffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+	cAllow := C.CString("," + strings.Join(opts.Tags, ",") + ",")
+	defer C.free(unsafe.Pointer(cAllow))
+
+	var ptr unsafe.Pointer
+	var length C.size_t
+	if C.mort_metadata(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cSuffix, mode, cAllow) != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// iccTransform converts colors of image to ICC profile, profile is name of built-in profile or path to .icc file
+func iccTransform(buf []byte, profile string) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	cProfile := C.CString(profile)
+	defer C.free(unsafe.Pointer(cProfile))
+
+	var ptr unsafe.Pointer
+	var length C.size_t
+	if C.mort_icc(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cProfile) != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// imagePages returns number of frames of animated image or pages of document
+func imagePages(buf []byte) int {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return 0
+	}
+
+	return int(C.mort_n_pages(unsafe.Pointer(&buf[0]), C.size_t(len(buf))))
+}
+
+// errRasterAreaExceeded frame or document page is too large to be rasterized
+var errRasterAreaExceeded = errors.New("rasterized image exceeds limit")
+
+// extractFrame returns single frame of animated image or page of document rendered with given dpi as lossless intermediate image
+// errRasterAreaExceeded is returned when frame is larger than maxArea pixels
+func extractFrame(buf []byte, frame int, dpi int, maxArea int) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	switch C.mort_frame(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, C.int(frame), C.int(dpi), C.guint64(maxArea)) {
+	case 0:
+	case 1:
+		return nil, fmt.Errorf("%w of %d pixels", errRasterAreaExceeded, maxArea)
+	default:
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// processAnimated resizes all frames of animated image
+func processAnimated(buf []byte, anim transforms.Animation) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	suffix, err := saveSuffix(anim.Format, anim.Quality)
+	if err != nil {
+		return nil, err
+	}
+
+	cSuffix := C.CString(suffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+
+	var ptr unsafe.Pointer
+	length := C.size_t(0)
+	ret := C.mort_animated(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cSuffix, C.int(anim.Width), C.int(anim.Height),
+		C.int(boolToInt(anim.Crop)), C.int(boolToInt(anim.Enlarge)), C.int(boolToInt(anim.Force)), C.int(anim.MaxFrames))
+	if ret != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// encodeWithOptions encodes image using libvips saver selected by suffix, saver options are passed in suffix
+func encodeWithOptions(buf []byte, suffix string) ([]byte, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return nil, errors.New("empty image buffer")
+	}
+
+	cSuffix := C.CString(suffix)
+	defer C.free(unsafe.Pointer(cSuffix))
+
+	var ptr unsafe.Pointer
+	var length C.size_t
+	if C.mort_save(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &ptr, &length, cSuffix) != 0 {
+		return nil, vipsError()
+	}
+
+	return vipsBytes(ptr, length), nil
+}
+
+// saveSuffix returns libvips save suffix with options for given format
+func saveSuffix(format string, quality int) (string, error) {
+	var suffix string
+	switch format {
+	case "jpeg", "jpg":
+		suffix = ".jpg"
+	case "webp":
+		suffix = ".webp"
+	case "avif":
+		suffix = ".avif"
+	case "heif":
+		suffix = ".heic"
+	case "png":
+		return ".png", nil
+	case "gif":
+		return ".gif", nil
+	default:
+		return "", errors.New("unable to save image in format " + format)
+	}
+
+	if quality != 0 {
+		suffix += "[Q=" + strconv.Itoa(quality) + "]"
+	}
+
+	return suffix, nil
+}
+
+// encodeImage encodes image to format that is not supported by bimg
+func encodeImage(buf []byte, enc transforms.Encoder) ([]byte, error) {
+	switch enc.Format {
+	case "jxl":
+		return encodeJxl(buf, enc)
+	case "blurhash":
+		return encodeBlurHash(buf)
+	case "lqip":
+		return encodeLQIP(buf, enc)
+	case "jpeg", "png", "webp", "avif", "heif", "gif":
+		return encodeWithOptions(buf, encoderSuffix(enc))
+	default:
+		return nil, errors.New("unsupported output format " + enc.Format)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// imageXMP returns XMP packet embedded in image, empty string when image has none
+func imageXMP(buf []byte) (string, error) {
+	defer C.vips_thread_shutdown()
+	if len(buf) == 0 {
+		return "", errors.New("empty image buffer")
+	}
+
+	var out unsafe.Pointer
+	var outLen C.size_t
+	if C.mort_xmp(unsafe.Pointer(&buf[0]), C.size_t(len(buf)), &out, &outLen) != 0 {
+		return "", vipsError()
+	}
+	if out == nil {
+		return "", nil
+	}
+
+	return string(vipsBytes(out, outLen)), nil
+}
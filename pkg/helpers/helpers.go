@@ -21,8 +21,25 @@ var client = &http.Client{
 	},
 }
 
+// FetchFnc is function which returns content of object for URI with registered scheme
+type FetchFnc func(uri string) ([]byte, error)
+
+// fetchers map of scheme to function used for fetching objects
+var fetchers = make(map[string]FetchFnc)
+
+// RegisterFetcher add function used for fetching URIs in form <scheme>://...
+func RegisterFetcher(scheme string, fn FetchFnc) {
+	fetchers[scheme] = fn
+}
+
 // FetchObject download data from given URI
 func FetchObject(uri string) ([]byte, error) {
+	if i := strings.Index(uri, "://"); i != -1 {
+		if fn, ok := fetchers[uri[:i]]; ok {
+			return fn(uri)
+		}
+	}
+
 	if strings.HasPrefix(uri, "http") {
 		req, err := http.NewRequest("GET", uri, nil)
 		if err != nil {
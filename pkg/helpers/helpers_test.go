@@ -176,6 +176,16 @@ func TestFetchObject(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestFetchObject_RegisteredScheme(t *testing.T) {
+	RegisterFetcher("test", func(uri string) ([]byte, error) {
+		return []byte(uri), nil
+	})
+
+	buf, err := FetchObject("test://bucket/image.png")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "test://bucket/image.png", string(buf))
+}
 func TestFetchObject_HTTPSSuccess(t *testing.T) {
 	defer gock.Off()
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -19,7 +20,7 @@ const Kind = "cloudinary"
 
 type (
 	Decoder struct {
-		cache     map[string]transforms.Transforms
+		cache     map[string][]transforms.Transforms
 		cacheLock sync.RWMutex
 	}
 )
@@ -31,33 +32,26 @@ func init() {
 
 func newCloudinaryDecoder() *Decoder {
 	return &Decoder{
-		cache: make(map[string]transforms.Transforms),
+		cache: make(map[string][]transforms.Transforms),
 	}
 }
 
-func (c *Decoder) getCached(definition string) (transforms.Transforms, bool) {
+func (c *Decoder) getCached(definition string) ([]transforms.Transforms, bool) {
 	c.cacheLock.RLock()
 	defer c.cacheLock.RUnlock()
 	t, exists := c.cache[definition]
 	return t, exists
 }
 
-func (c *Decoder) createTransformationsFromDefinition(definition string) (transforms.Transforms, error) {
+func (c *Decoder) createTransformationsFromDefinition(definition string) ([]transforms.Transforms, error) {
 	parser, err := newNotationParser(definition)
 	if err != nil {
-		return transforms.Transforms{}, err
+		return nil, err
 	}
-	transform, err := parser.NextTransform()
-	if err != nil {
-		return transforms.Transforms{}, err
-	}
-	if parser.HasNext() {
-		return transforms.Transforms{}, errors.New("multiple transforms are not supported")
-	}
-	return transform, nil
+	return parser.Transforms()
 }
 
-func (c *Decoder) getTransformations(transformationsDefinition string) (transforms.Transforms, error) {
+func (c *Decoder) getTransformations(transformationsDefinition string) ([]transforms.Transforms, error) {
 	var err error
 	t, ok := c.getCached(transformationsDefinition)
 	if !ok {
@@ -89,8 +83,7 @@ func (c *Decoder) decode(_ *url.URL, bucketConfig config.Bucket, obj *object.Fil
 
 	transformationsDefinition := subMatchMap["transformations"]
 	if transformationsDefinition != "" {
-		var err error
-		obj.Transforms, err = c.getTransformations(transformationsDefinition)
+		steps, err := c.getTransformations(transformationsDefinition)
 		if err != nil {
 			if errors.Is(err, notImplementedError{}) {
 				monitoring.Log().Error("Cloudinary", append([]zapcore.Field{zap.Error(err)}, obj.LogData()...)...)
@@ -99,6 +92,18 @@ func (c *Decoder) decode(_ *url.URL, bucketConfig config.Bucket, obj *object.Fil
 			monitoring.Log().Info("Cloudinary", append([]zapcore.Field{zap.Error(err)}, obj.LogData()...)...)
 			return "", err
 		}
+
+		for i := range steps {
+			if err = object.CheckLimits(trans.Limits, &steps[i]); err != nil {
+				return "", err
+			}
+		}
+
+		parentKey := subMatchMap["parent"]
+		if !strings.HasPrefix(parentKey, "/") {
+			parentKey = "/" + parentKey
+		}
+		setSteps(obj, steps, parentKey)
 	}
 
 	parent := subMatchMap["parent"]
@@ -110,3 +115,19 @@ func (c *Decoder) decode(_ *url.URL, bucketConfig config.Bucket, obj *object.Fil
 
 	return parent, nil
 }
+
+// setSteps assign last step of chain to object and create intermediate objects for previous ones
+// object parent is attached to last intermediate object by object.Parse
+func setSteps(obj *object.FileObject, steps []transforms.Transforms, parentKey string) {
+	if len(steps) == 0 {
+		return
+	}
+
+	obj.Transforms = steps[len(steps)-1]
+	curr := obj
+	for i := len(steps) - 2; i >= 0; i-- {
+		step := object.NewStepObject(obj.Bucket, parentKey+"-step"+strconv.Itoa(i), steps[i])
+		curr.Parent = step
+		curr = step
+	}
+}
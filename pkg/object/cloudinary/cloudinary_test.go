@@ -0,0 +1,225 @@
+package cloudinary
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cloudinaryConfig = `
+buckets:
+    cloudinary:
+        transform:
+            kind: "cloudinary"
+            parentBucket: "cloudinary"
+            path: "(?:\\/)(?P<transformations>[^\\/]+(?:\\/[a-z]+_[^\\/]+)*)?(?P<parent>\\/[^\\/]*)$"
+        storages:
+            basic:
+                kind: "http"
+                url: "https://example.com/<item>"
+            transform:
+                kind: "noop"
+`
+
+func TestNotationParserChain(t *testing.T) {
+	parser, err := newNotationParser("c_fill,w_300,h_200,g_north/e_grayscale/q_auto")
+	require.Nil(t, err)
+
+	steps, err := parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 2)
+
+	width, height := steps[0].Dimensions()
+	assert.Equal(t, 300, width)
+	assert.Equal(t, 200, height)
+	assert.Equal(t, []string{"resize"}, steps[0].Summary().Operations)
+	assert.Equal(t, []string{"grayscale"}, steps[1].Summary().Operations)
+}
+
+func TestNotationParserParams(t *testing.T) {
+	parser, err := newNotationParser("c_crop,w_100,h_100,g_face,q_80,f_webp,e_blur:400,a_-90,b_rgb:ff0000,dpr_2.0")
+	require.Nil(t, err)
+
+	steps, err := parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 1)
+
+	summary := steps[0].Summary()
+	assert.Equal(t, 200, summary.Width)
+	assert.Equal(t, 200, summary.Height)
+	assert.Equal(t, 80, summary.Quality)
+	assert.Equal(t, []string{"crop", "blur", "rotate"}, summary.Operations)
+	assert.Equal(t, "webp", steps[0].FormatStr)
+}
+
+func TestNotationParserMirrorTrim(t *testing.T) {
+	parser, err := newNotationParser("e_trim:20:rgb:000000,a_hflip/a_vflip")
+	require.Nil(t, err)
+
+	steps, err := parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, []string{"flop", "trim"}, steps[0].Summary().Operations)
+	assert.Equal(t, []string{"flip"}, steps[1].Summary().Operations)
+}
+
+func TestNotationParserRadius(t *testing.T) {
+	parser, err := newNotationParser("c_fill,w_100,h_100,r_max/r_20")
+	require.Nil(t, err)
+
+	steps, err := parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 2)
+	assert.Contains(t, steps[0].Summary().Operations, "mask")
+	assert.Equal(t, []string{"mask"}, steps[1].Summary().Operations)
+	assert.NotEqual(t, steps[0].HashStr(), steps[1].HashStr())
+}
+
+func TestNotationParserAuto(t *testing.T) {
+	parser, err := newNotationParser("f_auto,q_auto:eco/c_fit,w_100")
+	require.Nil(t, err)
+
+	steps, err := parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 1)
+	assert.True(t, steps[0].HasAutoFormat())
+	assert.Equal(t, "eco", steps[0].ToJSON()["autoQuality"])
+
+	parser, _ = newNotationParser("f_auto,q_auto")
+	steps, err = parser.Transforms()
+	require.Nil(t, err)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "good", steps[0].ToJSON()["autoQuality"])
+
+	parser, _ = newNotationParser("q_auto:ultra")
+	_, err = parser.Transforms()
+	assert.NotNil(t, err)
+}
+
+func TestNotationParserErrors(t *testing.T) {
+	tests := []string{
+		"c_pad,w_100",
+		"w_100,x_10",
+		"g_north_east,c_crop,w_100",
+		"a_45",
+		"e_sepia",
+		"e_trim:200",
+		"e_trim:10:nocolor",
+		"e_blur:5000",
+		"dpr_10",
+		"b_rgb:zzzzzz",
+		"f_bmp",
+		"w_abc",
+		"r_abc",
+		"r_0",
+	}
+
+	for _, definition := range tests {
+		t.Run(definition, func(t *testing.T) {
+			parser, err := newNotationParser(definition)
+			require.Nil(t, err)
+			_, err = parser.Transforms()
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestDecodeChain(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(cloudinaryConfig)
+	require.Nil(t, err)
+
+	u, _ := url.Parse("/cloudinary/c_fill,w_300/e_grayscale/a_90/image.jpg")
+	obj, err := object.NewFileObject(u, &mortConfig)
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"rotate"}, obj.Transforms.Summary().Operations)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, []string{"grayscale"}, obj.Parent.Transforms.Summary().Operations)
+	require.True(t, obj.Parent.HasParent())
+	assert.Equal(t, []string{"resize"}, obj.Parent.Parent.Transforms.Summary().Operations)
+	require.True(t, obj.Parent.Parent.HasParent())
+	assert.Equal(t, "/image.jpg", obj.Parent.Parent.Parent.Key)
+	assert.False(t, obj.Parent.Parent.Parent.HasParent())
+}
+
+func TestDecodeChainNotMerged(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(cloudinaryConfig)
+	require.Nil(t, err)
+
+	u, _ := url.Parse("/cloudinary/c_crop,w_100/c_scale,w_300/image.jpg")
+	obj, err := object.NewFileObject(u, &mortConfig)
+	require.Nil(t, err)
+
+	// chain is collected from the last step as in request processor
+	var steps []transforms.Transforms
+	for curr := obj; curr.HasParent(); curr = curr.Parent {
+		if curr.HasTransform() {
+			steps = append(steps, curr.Transforms)
+		}
+	}
+
+	merged := transforms.Merge(steps)
+	require.Len(t, merged, 2)
+	assert.Equal(t, []string{"crop"}, merged[0].Summary().Operations)
+	width, _ := merged[0].Dimensions()
+	assert.Equal(t, 100, width)
+	assert.Equal(t, []string{"resize"}, merged[1].Summary().Operations)
+	width, _ = merged[1].Dimensions()
+	assert.Equal(t, 300, width)
+}
+
+func TestDecodeSingle(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(cloudinaryConfig)
+	require.Nil(t, err)
+
+	u, _ := url.Parse("/cloudinary/c_fit,w_300/image.jpg")
+	obj, err := object.NewFileObject(u, &mortConfig)
+	require.Nil(t, err)
+
+	width, _ := obj.Transforms.Dimensions()
+	assert.Equal(t, 300, width)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "/image.jpg", obj.Parent.Key)
+}
+
+func TestDecodeMaxFramesCached(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(cloudinaryConfig + `
+    limited:
+        transform:
+            kind: "cloudinary"
+            parentBucket: "cloudinary"
+            path: "(?:\\/)(?P<transformations>[^\\/]+(?:\\/[a-z]+_[^\\/]+)*)?(?P<parent>\\/[^\\/]*)$"
+            limits:
+                maxFrames: 20
+        storages:
+            basic:
+                kind: "http"
+                url: "https://example.com/<item>"
+            transform:
+                kind: "noop"
+`)
+	require.Nil(t, err)
+
+	// second request uses transforms from decoder cache, they can't be changed by the first one
+	var hashes []string
+	for i := 0; i < 2; i++ {
+		u, _ := url.Parse("/limited/c_scale,w_100/image.gif")
+		obj, err := object.NewFileObject(u, &mortConfig)
+		require.Nil(t, err)
+
+		anim, ok := obj.Transforms.Animation("gif")
+		require.True(t, ok)
+		assert.Equal(t, 20, anim.MaxFrames)
+		hashes = append(hashes, obj.Transforms.HashStr())
+	}
+	assert.Equal(t, hashes[0], hashes[1])
+}
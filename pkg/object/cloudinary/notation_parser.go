@@ -3,6 +3,7 @@ package cloudinary
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -10,13 +11,8 @@ import (
 )
 
 type (
-	tokens struct {
-		entries      []token
-		currentIndex int
-	}
-
 	notationParser struct {
-		tokens *tokens
+		components [][]token
 	}
 
 	// token holds parsed cloudinary command
@@ -25,9 +21,20 @@ type (
 		PositionalArguments []string
 	}
 
-	qualifiers struct {
-		Width  uint
-		Height uint
+	// component holds parameters of single chained transformation (part of definition between "/")
+	component struct {
+		Crop       string
+		Width      uint
+		Height     uint
+		Gravity    string
+		Quality    int
+		Format     string
+		Angle      *int
+		Flip       bool
+		Flop       bool
+		Background string
+		Radius     string
+		Effects    []token
 	}
 
 	notImplementedError struct {
@@ -35,23 +42,47 @@ type (
 	}
 )
 
+// cloudinaryGravity maps cloudinary gravity names to mort ones
+var cloudinaryGravity = map[string]string{
+	"center": "center",
+	"north":  "north",
+	"south":  "south",
+	"east":   "east",
+	"west":   "west",
+	"auto":   "smart",
+	"face":   "smart",
+	"faces":  "smart",
+}
+
+const (
+	defaultBlurStrength = 100
+	maxDPR              = 5
+)
+
 func (e notImplementedError) Error() string {
 	return e.Message
 }
 
 func newNotationParser(source string) (*notationParser, error) {
-	plainTokens := strings.Split(source, ",")
-	parsedTokens := make([]token, len(plainTokens))
-	for i := 0; i < len(plainTokens); i++ {
-		parsedToken, err := parseToken(plainTokens[i])
-		if err != nil {
-			return nil, err
+	parser := &notationParser{}
+	for _, plainComponent := range strings.Split(source, "/") {
+		if plainComponent == "" {
+			continue
 		}
-		parsedTokens[i] = parsedToken
+
+		plainTokens := strings.Split(plainComponent, ",")
+		parsedTokens := make([]token, len(plainTokens))
+		for i := 0; i < len(plainTokens); i++ {
+			parsedToken, err := parseToken(plainTokens[i])
+			if err != nil {
+				return nil, err
+			}
+			parsedTokens[i] = parsedToken
+		}
+		parser.components = append(parser.components, parsedTokens)
 	}
-	return &notationParser{
-		tokens: &tokens{entries: parsedTokens},
-	}, nil
+
+	return parser, nil
 }
 
 func parseToken(plainToken string) (token, error) {
@@ -69,43 +100,95 @@ func parseToken(plainToken string) (token, error) {
 
 var errNoToken = errors.New("no token")
 
-func (t *tokens) Token() (token, bool) {
-	if t.currentIndex > len(t.entries)-1 {
-		return token{}, false
+// Transforms returns list of transformations, one for each chained component
+func (c *notationParser) Transforms() ([]transforms.Transforms, error) {
+	if len(c.components) == 0 {
+		return nil, errNoToken
 	}
-	// log.Printf("TOKEN: %+v\n", t.entries[t.currentIndex])
-	return t.entries[t.currentIndex], true
-}
 
-func (t *tokens) Next() {
-	t.currentIndex++
-}
+	result := make([]transforms.Transforms, 0, len(c.components))
+	dpr := 1.
+	autoFormat := false
+	autoQuality := ""
+	for _, tokens := range c.components {
+		comp := component{}
+		for _, t := range tokens {
+			switch {
+			case t.Name == "dpr":
+				v, err := parseDPR(t)
+				if err != nil {
+					return nil, err
+				}
+				if v != 0 {
+					dpr = v
+				}
+				continue
+			case t.Name == "f" && t.PositionalArguments[0] == "auto":
+				autoFormat = true
+				continue
+			case t.Name == "q" && t.PositionalArguments[0] == "auto":
+				autoQuality = "good"
+				if len(t.PositionalArguments) > 1 {
+					autoQuality = t.PositionalArguments[1]
+				}
+				continue
+			}
 
-func (t *tokens) HasNext() bool {
-	return t.currentIndex < len(t.entries)-1
-}
+			if err := comp.add(t); err != nil {
+				return nil, err
+			}
+		}
 
-func (c *notationParser) NextTransform() (transforms.Transforms, error) {
-	token, exists := c.tokens.Token()
-	if !exists {
-		return transforms.Transforms{}, errNoToken
-	}
-	trans := transforms.New()
-	switch token.Name {
-	case "c":
-		t, err := c.parseCrop(trans, token)
+		trans, err := comp.transforms()
 		if err != nil {
-			return transforms.Transforms{}, err
+			return nil, err
+		}
+
+		if trans.NotEmpty {
+			result = append(result, trans)
 		}
-		return t, nil
-	default:
-		return transforms.Transforms{}, notImplementedError{Message: fmt.Sprintf("unknown action %s", token.Name)}
 	}
 
-}
+	// automatic format and quality describe output of whole chain so they are set on last step
+	if autoFormat || autoQuality != "" {
+		if len(result) == 0 {
+			result = append(result, transforms.New())
+		}
+
+		last := &result[len(result)-1]
+		if autoFormat {
+			last.AutoFormat()
+		}
+
+		if autoQuality != "" {
+			if err := last.AutoQuality(autoQuality); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-func (c *notationParser) HasNext() bool {
-	return c.tokens.HasNext()
+	// device pixel ratio is applied to whole chain
+	if dpr != 1 {
+		for i := range result {
+			width, height := result[i].Dimensions()
+			if width == 0 && height == 0 {
+				continue
+			}
+			err := result[i].SetDimensions(int(math.Round(float64(width)*dpr)), int(math.Round(float64(height)*dpr)))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// each component of chain is applied on result of previous one so steps can't be merged into single transform
+	if len(result) > 1 {
+		for i := range result {
+			result[i].NoMerge = true
+		}
+	}
+
+	return result, nil
 }
 
 func parseUint(t token) (uint, error) {
@@ -122,62 +205,226 @@ func parseUint(t token) (uint, error) {
 	return uint(v), nil
 }
 
-func (c *notationParser) parseQualifiers() (qualifiers, error) {
-	result := qualifiers{}
-	for {
-		c.tokens.Next()
-		token, exists := c.tokens.Token()
-		if !exists {
-			return result, nil
-		}
-		switch token.Name {
-		case "w":
-			v, err := parseUint(token)
-			if err != nil {
-				return result, err
-			}
-			result.Width = v
-		case "h":
-			v, err := parseUint(token)
-			if err != nil {
-				return result, err
-			}
-			result.Height = v
-		case "b", "ar", "g", "bo", "co", "x", "y":
-			return result, notImplementedError{Message: fmt.Sprintf("'%s' qualifier is not implemented", token.Name)}
-		default:
+// parseDPR returns device pixel ratio, 0 means that dpr should not be changed
+func parseDPR(t token) (float64, error) {
+	if len(t.PositionalArguments) != 1 {
+		return 0, errors.New("no value provided for 'dpr'")
+	}
 
+	// dpr_auto depends on client hints which are handled by client-hints plugin
+	if t.PositionalArguments[0] == "auto" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseFloat(t.PositionalArguments[0], 64)
+	if err != nil || v <= 0 || v > maxDPR {
+		return 0, fmt.Errorf("invalid dpr value '%s'", t.PositionalArguments[0])
+	}
+
+	return v, nil
+}
+
+// add parse token and store its value in component
+func (c *component) add(t token) error {
+	switch t.Name {
+	case "c":
+		if len(t.PositionalArguments) != 1 {
+			return errors.New("crop requires mode")
+		}
+		c.Crop = t.PositionalArguments[0]
+	case "w":
+		v, err := parseUint(t)
+		if err != nil {
+			return err
+		}
+		c.Width = v
+	case "h":
+		v, err := parseUint(t)
+		if err != nil {
+			return err
+		}
+		c.Height = v
+	case "g":
+		g, ok := cloudinaryGravity[t.PositionalArguments[0]]
+		if !ok {
+			return notImplementedError{Message: fmt.Sprintf("'%s' gravity is not implemented", t.PositionalArguments[0])}
+		}
+		c.Gravity = g
+	case "q":
+		v, err := parseUint(t)
+		if err != nil {
+			return err
+		}
+		c.Quality = int(v)
+	case "f":
+		c.Format = t.PositionalArguments[0]
+	case "a":
+		switch t.PositionalArguments[0] {
+		case "hflip":
+			c.Flop = true
+			return nil
+		case "vflip":
+			c.Flip = true
+			return nil
+		}
+		v, err := strconv.Atoi(t.PositionalArguments[0])
+		if err != nil {
+			return fmt.Errorf("value '%s' is not an integer but expected for 'a'", t.PositionalArguments[0])
+		}
+		c.Angle = &v
+	case "b":
+		switch {
+		case len(t.PositionalArguments) == 2 && t.PositionalArguments[0] == "rgb":
+			c.Background = "#" + t.PositionalArguments[1]
+		case len(t.PositionalArguments) == 1 && t.PositionalArguments[0] != "auto":
+			c.Background = t.PositionalArguments[0]
+		default:
+			return notImplementedError{Message: fmt.Sprintf("'%s' background is not implemented", strings.Join(t.PositionalArguments, ":"))}
 		}
+	case "r":
+		if len(t.PositionalArguments) != 1 {
+			return notImplementedError{Message: fmt.Sprintf("'%s' radius is not implemented", strings.Join(t.PositionalArguments, ":"))}
+		}
+		c.Radius = t.PositionalArguments[0]
+	case "e":
+		c.Effects = append(c.Effects, t)
+	default:
+		return notImplementedError{Message: fmt.Sprintf("'%s' parameter is not implemented", t.Name)}
 	}
+
+	return nil
 }
 
-func (c *notationParser) parseCrop(result transforms.Transforms, token token) (transforms.Transforms, error) {
-	if len(token.PositionalArguments) != 1 {
-		return result, errors.New("crop requires mode")
+// transforms creates mort transformation from component
+func (c *component) transforms() (transforms.Transforms, error) {
+	result := transforms.New()
+	if err := c.resize(&result); err != nil {
+		return result, err
 	}
-	switch token.PositionalArguments[0] {
-	case "fit":
-		qualifiers, err := c.parseQualifiers()
-		if err != nil {
+
+	if c.Gravity != "" && c.Crop != "crop" {
+		if err := result.Gravity(c.Gravity); err != nil {
 			return result, err
 		}
-		err = result.Resize(int(qualifiers.Width), int(qualifiers.Height), false, true, false)
-		return result, err
-	case "fill":
-		qualifiers, err := c.parseQualifiers()
-		if err != nil {
+	}
+
+	for _, e := range c.Effects {
+		if err := applyEffect(&result, e); err != nil {
 			return result, err
 		}
-		err = result.Resize(int(qualifiers.Width), int(qualifiers.Height), true, true, true)
-		return result, err
-	case "crop":
-		qualifiers, err := c.parseQualifiers()
-		if err != nil {
+	}
+
+	if c.Angle != nil {
+		// rotation in cloudinary can be negative or bigger than full angle
+		if err := result.Rotate((*c.Angle%360 + 360) % 360); err != nil {
 			return result, err
 		}
-		err = result.Crop(int(qualifiers.Width), int(qualifiers.Height), "", false, false)
-		return result, err
+	}
+
+	if c.Flip {
+		result.Flip()
+	}
+
+	if c.Flop {
+		result.Flop()
+	}
+
+	if c.Radius != "" {
+		if err := c.mask(&result); err != nil {
+			return result, err
+		}
+	}
+
+	if c.Background != "" {
+		if err := result.Background(c.Background); err != nil {
+			return result, err
+		}
+	}
+
+	if c.Quality != 0 {
+		if err := result.Quality(c.Quality); err != nil {
+			return result, err
+		}
+	}
+
+	if c.Format != "" {
+		if err := result.Format(c.Format); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// mask creates rounded corners, r_max makes circle
+func (c *component) mask(result *transforms.Transforms) error {
+	if c.Radius == "max" {
+		return result.Mask("circle", 0, "")
+	}
+
+	radius, err := strconv.Atoi(c.Radius)
+	if err != nil {
+		return fmt.Errorf("value '%s' is not an integer but expected for 'r'", c.Radius)
+	}
+
+	return result.Mask("rounded", radius, "")
+}
+
+func (c *component) resize(result *transforms.Transforms) error {
+	width, height := int(c.Width), int(c.Height)
+	switch c.Crop {
+	case "":
+		if width == 0 && height == 0 {
+			return nil
+		}
+		return result.Resize(width, height, true, false, false)
+	case "scale":
+		return result.Resize(width, height, true, false, false)
+	case "fit":
+		return result.Resize(width, height, false, true, false)
+	case "fill":
+		return result.Resize(width, height, true, true, true)
+	case "crop":
+		return result.Crop(width, height, c.Gravity, false, false)
+	default:
+		return notImplementedError{Message: fmt.Sprintf("'%s' is not implemented for crop action", c.Crop)}
+	}
+}
+
+func applyEffect(result *transforms.Transforms, t token) error {
+	switch t.PositionalArguments[0] {
+	case "grayscale":
+		result.Grayscale()
+		return nil
+	case "blur":
+		strength := defaultBlurStrength
+		if len(t.PositionalArguments) > 1 {
+			v, err := strconv.Atoi(t.PositionalArguments[1])
+			if err != nil || v < 1 || v > 2000 {
+				return fmt.Errorf("invalid blur strength '%s'", t.PositionalArguments[1])
+			}
+			strength = v
+		}
+		// cloudinary strength 1-2000 is mapped to gaussian sigma 0.05-100
+		return result.Blur(float64(strength)/20, 0)
+	case "trim":
+		var tolerance float64
+		var background string
+		if len(t.PositionalArguments) > 1 {
+			v, err := strconv.Atoi(t.PositionalArguments[1])
+			if err != nil || v < 0 || v > 100 {
+				return fmt.Errorf("invalid trim tolerance '%s'", t.PositionalArguments[1])
+			}
+			tolerance = float64(v)
+		}
+		switch {
+		case len(t.PositionalArguments) == 4 && t.PositionalArguments[2] == "rgb":
+			background = "#" + t.PositionalArguments[3]
+		case len(t.PositionalArguments) > 2:
+			background = t.PositionalArguments[2]
+		}
+		return result.Trim(tolerance, background)
 	default:
-		return result, notImplementedError{Message: fmt.Sprintf("'%s' is not implemented for crop action", token.PositionalArguments[0])}
+		return notImplementedError{Message: fmt.Sprintf("'%s' effect is not implemented", t.PositionalArguments[0])}
 	}
 }
@@ -34,6 +34,7 @@ type FileObject struct {
 	Ctx            context.Context       // context of request
 	Range          string                // HTTP range in request
 	RangeData      httpRange             // start, end for HTTP range
+	Limits         *config.Limits        // limits of bucket transforms, checked again when object is changed after parsing
 }
 
 // NewFileObjectFromPath create new instance of FileObject
@@ -89,6 +90,20 @@ func NewFileObject(uri *url.URL, mortConfig *config.Config) (*FileObject, error)
 	return &obj, err
 }
 
+// NewStepObject create intermediate object of multi step transformation
+// key is storage path with leading slash, parser should set returned object as a parent
+// and Parse will attach real parent at the end of chain
+func NewStepObject(bucket, key string, trans transforms.Transforms) *FileObject {
+	return &FileObject{
+		Uri:        &url.URL{Path: "/" + bucket + key},
+		Bucket:     bucket,
+		Key:        key,
+		key:        strings.TrimPrefix(key, "/"),
+		Transforms: trans,
+		Storage:    config.Storage{Kind: "noop"},
+	}
+}
+
 // HasParent inform if object has parent
 func (o *FileObject) HasParent() bool {
 	return o.Parent != nil
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aldor007/mort/pkg/config"
 	"github.com/aldor007/mort/pkg/transforms"
@@ -71,6 +72,27 @@ func TestNewFileObjectTransform(t *testing.T) {
 
 }
 
+func TestNewFileObjectBase64PresetMirror(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-base64.yml")
+	require.Nil(t, err)
+
+	obj, err := NewFileObject(pathToURL("/bucket/mirror/cGFyZW50LmpwZw"), &mortConfig)
+	require.Nil(t, err)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "/parent.jpg", obj.Parent.Key)
+	assert.Equal(t, []string{"flip", "flop", "trim", "zoom"}, obj.Transforms.Summary().Operations)
+
+	opts, err := obj.Transforms.BimgOptions(imageInfo)
+	require.Nil(t, err)
+	require.Len(t, opts, 3)
+	assert.True(t, opts[0].Trim)
+	assert.Equal(t, 20., opts[0].Threshold)
+	assert.True(t, opts[1].Flip)
+	assert.True(t, opts[1].Flop)
+	assert.Equal(t, 1, opts[2].Zoom)
+}
+
 func TestNewFileObjectTransformParentBucket(t *testing.T) {
 	mortConfig := config.GetInstance()
 	mortConfig.Load("testdata/bucket-transform-parent-bucket.yml")
@@ -182,6 +204,27 @@ func TestNewFileObjecWithNestedParentHash(t *testing.T) {
 	assert.Equal(t, "/6ca/hei/height-bucket-parent.jpg-6ca0dabe9909875a", obj.Key)
 }
 
+func TestNewFileObjectMetadataPolicy(t *testing.T) {
+	mortConfig := &config.Config{}
+	require.Nil(t, mortConfig.Load("testdata/bucket-transform-metadata.yml"))
+
+	obj, err := NewFileObject(pathToURL("/bucket/small/parent.jpg"), mortConfig)
+	require.Nil(t, err)
+	opts, ok := obj.Transforms.MetadataOptions()
+	assert.True(t, ok, "bucket policy should be used")
+	assert.Equal(t, "nogps", opts.Policy)
+
+	obj, err = NewFileObject(pathToURL("/bucket/legal/parent.jpg"), mortConfig)
+	require.Nil(t, err)
+	opts, ok = obj.Transforms.MetadataOptions()
+	assert.True(t, ok)
+	assert.Equal(t, "allowlist", opts.Policy, "preset policy should override bucket one")
+	assert.Equal(t, []string{"Artist", "Copyright"}, opts.Tags)
+
+	// parent is not transformed
+	assert.False(t, obj.Parent.HasTransform())
+}
+
 func TestNewFileObjecWithNestedParentHashParent(t *testing.T) {
 	mortConfig := config.GetInstance()
 	mortConfig.Load("testdata/bucket-transform-hashParent.yml")
@@ -415,6 +458,44 @@ func TestNewFileObjectPresetPresetBlur(t *testing.T) {
 	assert.Equal(t, transCfg.Interpretation, bimg.InterpretationBW)
 }
 
+func TestNewFileObjectPresetAvif(t *testing.T) {
+	mortConfig := &config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-preset-query.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := NewFileObject(pathToURL("/bucket/avif/parent.jpg"), mortConfig)
+	assert.Nil(t, err, "Unexpected to have error when parsing path")
+	assert.True(t, obj.HasTransform(), "obj should have transforms")
+	assert.Equal(t, "avif", obj.Transforms.FormatStr)
+
+	transCfgArr, err := obj.Transforms.BimgOptions(imageInfo)
+	assert.Nil(t, err, "Unexpected to have error when getting transforms")
+	transCfg := transCfgArr[0]
+
+	assert.Equal(t, bimg.AVIF, transCfg.Type)
+	assert.Equal(t, 7, transCfg.Speed)
+	assert.True(t, transCfg.Lossless)
+	assert.Equal(t, 60, transCfg.Quality)
+}
+
+func TestNewFileObjectPresetPad(t *testing.T) {
+	mortConfig := &config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-preset-query.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := NewFileObject(pathToURL("/bucket/pad/parent.jpg"), mortConfig)
+	assert.Nil(t, err, "Unexpected to have error when parsing path")
+	assert.True(t, obj.HasTransform(), "obj should have transforms")
+
+	ext, ok := obj.Transforms.ExtendOptions()
+	assert.True(t, ok, "pad should extend image")
+	assert.Equal(t, 300, ext.Width)
+	assert.Equal(t, 200, ext.Height)
+	assert.Equal(t, uint8(0), ext.Alpha)
+}
+
 func TestNewFileUnknownPreset(t *testing.T) {
 	mortConfig := &config.Config{}
 	err := mortConfig.Load("testdata/bucket-transform-preset-query.yml")
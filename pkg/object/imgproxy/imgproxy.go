@@ -0,0 +1,136 @@
+package imgproxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+)
+
+// Kind name of imgproxy transform kind
+const Kind = "imgproxy"
+
+func init() {
+	object.RegisterParser(Kind, decode)
+}
+
+// decode parse imgproxy URL in form /<signature>/<options>/plain/<source>@<ext> or /<signature>/<options>/<base64 source>.<ext>
+func decode(u *url.URL, bucketConfig config.Bucket, obj *object.FileObject) (string, error) {
+	trans := bucketConfig.Transform
+	// signature is computed from path as it was sent by client
+	escapedPath := strings.TrimPrefix(u.EscapedPath(), "/"+obj.Bucket)
+	elements := strings.SplitN(strings.TrimPrefix(escapedPath, "/"), "/", 2)
+	if len(elements) != 2 {
+		return "", errors.New("invalid imgproxy path")
+	}
+
+	if err := verifySignature(trans.Imgproxy, elements[0], "/"+elements[1]); err != nil {
+		return "", err
+	}
+
+	opts, source, err := parsePath(elements[1])
+	if err != nil {
+		return "", err
+	}
+
+	obj.Transforms, err = opts.transforms()
+	if err != nil {
+		return "", err
+	}
+
+	if err = object.CheckLimits(trans.Limits, &obj.Transforms); err != nil {
+		return "", err
+	}
+
+	return object.ParentFromURL(source, trans, obj.Bucket)
+}
+
+// verifySignature checks signature of path, URLs are not verified only when insecure mode is explicitly enabled
+func verifySignature(cfg *config.ImgproxyCfg, signature, signedPath string) error {
+	if cfg == nil {
+		return fmt.Errorf("%w: imgproxy is not configured", object.ErrInvalidSignature)
+	}
+
+	if cfg.Insecure {
+		return nil
+	}
+
+	if cfg.Key == "" || cfg.Salt == "" {
+		return fmt.Errorf("%w: imgproxy key and salt are not configured", object.ErrInvalidSignature)
+	}
+
+	key, err := hex.DecodeString(cfg.Key)
+	if err != nil {
+		return fmt.Errorf("invalid imgproxy key: %w", err)
+	}
+
+	salt, err := hex.DecodeString(cfg.Salt)
+	if err != nil {
+		return fmt.Errorf("invalid imgproxy salt: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(salt)
+	mac.Write([]byte(signedPath))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(strings.TrimRight(signature, "=")), []byte(expected)) {
+		return fmt.Errorf("%w: imgproxy signature mismatch", object.ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// parsePath splits escaped path (without signature) to processing options and source URL
+func parsePath(p string) (options, string, error) {
+	opts := newOptions()
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		if segment == "plain" {
+			source, err := url.PathUnescape(strings.Join(segments[i+1:], "/"))
+			if err != nil {
+				return opts, "", err
+			}
+
+			if at := strings.LastIndex(source, "@"); at != -1 {
+				opts.format = source[at+1:]
+				source = source[:at]
+			}
+
+			return opts, source, nil
+		}
+
+		if !strings.Contains(segment, ":") {
+			source, err := decodeSource(&opts, strings.Join(segments[i:], ""))
+			return opts, source, err
+		}
+
+		if err := opts.parse(segment); err != nil {
+			return opts, "", err
+		}
+	}
+
+	return opts, "", errors.New("missing source URL")
+}
+
+// decodeSource decodes base64 encoded source URL with optional extension
+func decodeSource(opts *options, encoded string) (string, error) {
+	if dot := strings.LastIndex(encoded, "."); dot != -1 {
+		opts.format = encoded[dot+1:]
+		encoded = encoded[:dot]
+	}
+
+	source, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return "", errors.New("invalid base64 source URL")
+	}
+
+	return string(source), nil
+}
@@ -0,0 +1,174 @@
+package imgproxy
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imgproxyConfig = `
+buckets:
+    imgproxy:
+        transform:
+            kind: "imgproxy"
+            parentBucket: "media"
+            imgproxy:
+                key: "943b421c9eb07c830af81030552c86009268de4e532ba2ee2eab8247c6da0881"
+                salt: "520f986b998545b4785e0defbc4f3c1203f22de2374a3d53cb7a7fe9fea309c5"
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    unsafe:
+        transform:
+            kind: "imgproxy"
+            parentBuckets: ["media"]
+            imgproxy:
+                insecure: true
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    noconfig:
+        transform:
+            kind: "imgproxy"
+            parentBucket: "media"
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    media:
+        storages:
+            basic:
+                kind: "noop"
+`
+
+func loadConfig(t *testing.T) *config.Config {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(imgproxyConfig)
+	require.Nil(t, err)
+	return &mortConfig
+}
+
+func TestDecodeSignedBase64(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	u, _ := url.Parse("/imgproxy/90UxdwGRAI2bpLSHKkZculJau5ahfxfS0h3fMuQAf40/rs:fill:300:400:0/g:sm/aHR0cDovL2V4YW1w/bGUuY29tL2ltYWdl/cy9jdXJpb3NpdHku/anBn.png")
+	obj, err := object.NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 300, width)
+	assert.Equal(t, 400, height)
+	assert.Equal(t, "png", obj.Transforms.FormatStr)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "media", obj.Parent.Bucket)
+	assert.Equal(t, "/images/curiosity.jpg", obj.Parent.Key)
+}
+
+func TestDecodeInvalidSignature(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	u, _ := url.Parse("/imgproxy/90UxdwGRAI2bpLSHKkZculJau5ahfxfS0h3fMuQAf40/rs:fill:301:400:0/g:sm/aHR0cDovL2V4YW1w/bGUuY29tL2ltYWdl/cy9jdXJpb3NpdHku/anBn.png")
+	_, err := object.NewFileObject(u, mortConfig)
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+
+	u, _ = url.Parse("/imgproxy/unsafe/rs:fill:300:400/plain/local:///images/a.jpg")
+	_, err = object.NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+
+	// without config URLs are not accepted
+	u, _ = url.Parse("/noconfig/insecure/rs:fill:300:400/plain/local:///images/a.jpg")
+	_, err = object.NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+}
+
+func TestVerifySignatureInvalidKey(t *testing.T) {
+	err := verifySignature(&config.ImgproxyCfg{Key: "not-hex", Salt: "abcd"}, "sig", "/w:100/plain/a.jpg")
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, object.ErrInvalidSignature))
+
+	err = verifySignature(&config.ImgproxyCfg{Key: "abcd", Salt: "not-hex"}, "sig", "/w:100/plain/a.jpg")
+	assert.NotNil(t, err)
+}
+
+func TestImgproxyConfig(t *testing.T) {
+	tests := []struct {
+		imgproxy string
+		valid    bool
+	}{
+		{"key: \"abcd\"\n                salt: \"ef01\"", true},
+		{`insecure: true`, true},
+		{`insecure: false`, false},
+		{`key: "abcd"`, false},
+		{"key: \"xyz\"\n                salt: \"ef01\"", false},
+		{"key: \"abcd\"\n                salt: \"ef01\"\n                insecure: true", false},
+	}
+
+	for _, tt := range tests {
+		mortConfig := config.Config{}
+		err := mortConfig.LoadFromString(`
+buckets:
+    imgproxy:
+        transform:
+            kind: "imgproxy"
+            imgproxy:
+                ` + tt.imgproxy + `
+        storages:
+            basic:
+                kind: "noop"
+`)
+		assert.Equal(t, tt.valid, err == nil, tt.imgproxy)
+	}
+}
+
+func TestDecodeUnsafePlain(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	u, _ := url.Parse("/unsafe/insecure/w:200/dpr:2/q:80/sm:1/plain/s3:%2F%2Fmedia%2Fdir%2Fimg.jpg@webp")
+	obj, err := object.NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 400, width)
+	assert.Equal(t, 0, height)
+	assert.Equal(t, "webp", obj.Transforms.FormatStr)
+	assert.Equal(t, 80, obj.Transforms.Summary().Quality)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "media", obj.Parent.Bucket)
+	assert.Equal(t, "/dir/img.jpg", obj.Parent.Key)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	tests := []string{
+		"/unsafe/_/rs:crop:100:100/plain/media/img.jpg",
+		"/unsafe/_/g:fp:0.5:0.5/plain/media/img.jpg",
+		"/unsafe/_/rs:fill:100:100:1:1/plain/media/img.jpg",
+		"/unsafe/_/pr:thumb/plain/media/img.jpg",
+		"/unsafe/_/w:abc/plain/media/img.jpg",
+		"/unsafe/_/w:100",
+		"/unsafe/_/w:100/!!!invalid.jpg",
+		"/unsafe/_",
+		"/unsafe/_/w:100/plain/s3:%2F%2Fimgproxy%2Fimg.jpg",
+		"/unsafe/_/w:100/plain/media/..%2Fimgproxy%2Fimg.jpg",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			u, _ := url.Parse(path)
+			_, err := object.NewFileObject(u, mortConfig)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package imgproxy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// options holds imgproxy processing options
+type options struct {
+	resizingType string
+	width        int
+	height       int
+	enlarge      bool
+	gravity      string
+	quality      int
+	format       string
+	blur         float64
+	rotate       int
+	dpr          float64
+	strip        bool
+}
+
+// imgproxyGravity maps imgproxy gravity types to mort ones
+var imgproxyGravity = map[string]string{
+	"no": "north",
+	"so": "south",
+	"ea": "east",
+	"we": "west",
+	"ce": "center",
+	"sm": "smart",
+}
+
+func newOptions() options {
+	return options{resizingType: "fit", dpr: 1}
+}
+
+// parse parses single processing option in form name:arg1:arg2
+func (o *options) parse(option string) error {
+	args := strings.Split(option, ":")
+	name := args[0]
+	args = args[1:]
+
+	var err error
+	switch name {
+	case "resize", "rs":
+		if err = o.setResizingType(arg(args, 0)); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			err = o.setSize(args[1:])
+		}
+	case "size", "s":
+		err = o.setSize(args)
+	case "resizing_type", "rt":
+		err = o.setResizingType(arg(args, 0))
+	case "width", "w":
+		o.width, err = parseInt(name, arg(args, 0))
+	case "height", "h":
+		o.height, err = parseInt(name, arg(args, 0))
+	case "enlarge", "el":
+		o.enlarge = parseBool(arg(args, 0))
+	case "gravity", "g":
+		g, ok := imgproxyGravity[arg(args, 0)]
+		if !ok {
+			return fmt.Errorf("gravity %s is not supported", arg(args, 0))
+		}
+		o.gravity = g
+	case "quality", "q":
+		o.quality, err = parseInt(name, arg(args, 0))
+	case "format", "f", "ext":
+		o.format = arg(args, 0)
+	case "blur", "bl":
+		o.blur, err = strconv.ParseFloat(arg(args, 0), 64)
+	case "rotate", "rot":
+		o.rotate, err = parseInt(name, arg(args, 0))
+	case "dpr":
+		o.dpr, err = strconv.ParseFloat(arg(args, 0), 64)
+		if err == nil && o.dpr <= 0 {
+			err = errors.New("dpr has to be positive")
+		}
+	case "strip_metadata", "sm":
+		o.strip = parseBool(arg(args, 0))
+	default:
+		return fmt.Errorf("processing option %s is not supported", name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s option: %w", name, err)
+	}
+
+	return nil
+}
+
+func (o *options) setResizingType(resizingType string) error {
+	switch resizingType {
+	case "":
+	case "fit", "fill", "fill-down", "force", "auto":
+		o.resizingType = resizingType
+	default:
+		return fmt.Errorf("resizing type %s is not supported", resizingType)
+	}
+
+	return nil
+}
+
+// setSize parses arguments of size option width:height:enlarge:extend
+func (o *options) setSize(args []string) error {
+	var err error
+	if v := arg(args, 0); v != "" {
+		if o.width, err = parseInt("width", v); err != nil {
+			return err
+		}
+	}
+
+	if v := arg(args, 1); v != "" {
+		if o.height, err = parseInt("height", v); err != nil {
+			return err
+		}
+	}
+
+	if v := arg(args, 2); v != "" {
+		o.enlarge = parseBool(v)
+	}
+
+	if parseBool(arg(args, 3)) {
+		return errors.New("extend is not supported")
+	}
+
+	return nil
+}
+
+// transforms creates mort transformation from options
+func (o *options) transforms() (transforms.Transforms, error) {
+	result := transforms.New()
+	width := int(math.Round(float64(o.width) * o.dpr))
+	height := int(math.Round(float64(o.height) * o.dpr))
+
+	if width != 0 || height != 0 {
+		var err error
+		switch o.resizingType {
+		case "fit":
+			err = result.Resize(width, height, o.enlarge, true, false)
+		case "fill", "auto":
+			// auto depends on orientation of source image which is unknown while parsing, so fill is used
+			err = result.Resize(width, height, o.enlarge, true, true)
+		case "fill-down":
+			err = result.Resize(width, height, false, true, true)
+		case "force":
+			err = result.Resize(width, height, o.enlarge, false, false)
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if o.gravity != "" {
+		if err := result.Gravity(o.gravity); err != nil {
+			return result, err
+		}
+	}
+
+	if o.blur > 0 {
+		if err := result.Blur(o.blur, 0); err != nil {
+			return result, err
+		}
+	}
+
+	if o.rotate != 0 {
+		if err := result.Rotate(o.rotate); err != nil {
+			return result, err
+		}
+	}
+
+	if o.quality != 0 {
+		if err := result.Quality(o.quality); err != nil {
+			return result, err
+		}
+	}
+
+	if o.strip {
+		result.StripMetadata()
+	}
+
+	if o.format != "" {
+		if err := result.Format(o.format); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return ""
+}
+
+func parseInt(name, value string) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("value '%s' is not an integer but expected for '%s'", value, name)
+	}
+
+	if v < 0 {
+		return 0, fmt.Errorf("value '%s' cannot be negative for '%s'", value, name)
+	}
+
+	return v, nil
+}
+
+func parseBool(value string) bool {
+	return value == "1" || value == "t" || value == "true"
+}
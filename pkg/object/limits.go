@@ -0,0 +1,58 @@
+package object
+
+import (
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// CheckLimits returns error if transforms don't fit into bucket limits, transforms are not changed
+func CheckLimits(limits *config.Limits, trans *transforms.Transforms) error {
+	if limits == nil || !trans.NotEmpty {
+		return nil
+	}
+
+	summary := trans.Summary()
+	for _, op := range summary.Operations {
+		if err := limits.CheckOperation(op); err != nil {
+			return err
+		}
+	}
+
+	if err := limits.CheckSize(summary.Width, summary.Height); err != nil {
+		return err
+	}
+
+	if err := limits.CheckDPI(summary.DPI); err != nil {
+		return err
+	}
+
+	return limits.CheckQuality(summary.Quality)
+}
+
+// applyEngineLimits sets limits of processed animation frames and rasterized area on transforms of object and its intermediate steps
+// it has to be done before key of object is created, as limits are part of transforms hash
+func applyEngineLimits(limits *config.Limits, obj, parent *FileObject) error {
+	if limits == nil || (limits.MaxFrames <= 0 && limits.MaxRasterArea <= 0) {
+		return nil
+	}
+
+	for curr := obj; curr != nil && curr != parent; curr = curr.Parent {
+		if !curr.Transforms.NotEmpty {
+			continue
+		}
+
+		if limits.MaxFrames > 0 {
+			if err := curr.Transforms.MaxFrames(limits.MaxFrames); err != nil {
+				return err
+			}
+		}
+
+		if limits.MaxRasterArea > 0 {
+			if err := curr.Transforms.MaxRasterArea(limits.MaxRasterArea); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package object
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLimits(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-limits.yml")
+	require.Nil(t, err)
+
+	tests := []struct {
+		name   string
+		query  string
+		errMsg string
+	}{
+		{"allowed width", "width=200", ""},
+		{"allowed crop", "operation=crop&width=200&height=200&grayscale=1&quality=80", ""},
+		{"not allowed width", "width=4999", "width 4999 is not allowed"},
+		{"not multiple of step", "width=100&height=105", "multiple of 10"},
+		{"area too big", "width=400&height=400", "exceeds limit"},
+		{"area of single dimension", "operation=resize&width=400", "exceeds limit"},
+		{"not allowed operation", "operation=blur&sigma=2", "operation blur is not allowed"},
+		{"quality too high", "width=100&quality=95", "max quality is 80"},
+		{"allowed dpi", "width=200&dpi=150", ""},
+		{"dpi too high", "width=200&dpi=300", "max dpi is 150"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("/bucket/image.jpg?" + tt.query)
+			_, err := NewFileObject(u, &mortConfig)
+			if tt.errMsg == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestCheckLimitsMaxFrames(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-limits.yml")
+	require.Nil(t, err)
+
+	u, _ := url.Parse("/bucket/image.gif?width=200")
+	obj, err := NewFileObject(u, &mortConfig)
+	require.Nil(t, err)
+
+	anim, ok := obj.Transforms.Animation("gif")
+	require.True(t, ok)
+	assert.Equal(t, 20, anim.MaxFrames)
+	assert.Equal(t, 4000000, obj.Transforms.RasterArea())
+}
+
+func TestCheckLimitsDoesNotChangeTransforms(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-limits.yml")
+	require.Nil(t, err)
+
+	trans := transforms.New()
+	trans.Resize(200, 0, false, false, false)
+	hashStr := trans.HashStr()
+
+	require.Nil(t, CheckLimits(mortConfig.Buckets["bucket"].Transform.Limits, &trans))
+	assert.Equal(t, hashStr, trans.HashStr())
+	anim, ok := trans.Animation("gif")
+	require.True(t, ok)
+	assert.Equal(t, transforms.DefaultMaxFrames, anim.MaxFrames)
+	assert.Equal(t, transforms.DefaultMaxRasterArea, trans.RasterArea())
+}
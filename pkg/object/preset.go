@@ -110,6 +110,12 @@ func presetToTransform(preset config.Preset) (transforms.Transforms, error) {
 			return trans, err
 		}
 	}
+	if filters.FocalPoint != nil {
+		err := trans.FocalPoint(filters.FocalPoint.X, filters.FocalPoint.Y)
+		if err != nil {
+			return trans, err
+		}
+	}
 	trans.Quality(preset.Quality)
 
 	if filters.Interlace == true {
@@ -126,6 +132,14 @@ func presetToTransform(preset config.Preset) (transforms.Transforms, error) {
 		}
 	}
 
+	if filters.ColorProfile != nil {
+		embed := filters.ColorProfile.Embed == nil || *filters.ColorProfile.Embed
+		err := trans.ColorProfile(filters.ColorProfile.Target, embed)
+		if err != nil {
+			return trans, err
+		}
+	}
+
 	if preset.Format != "" {
 		err := trans.Format(preset.Format)
 		if err != nil {
@@ -133,6 +147,64 @@ func presetToTransform(preset config.Preset) (transforms.Transforms, error) {
 		}
 	}
 
+	if preset.MaxBytes != nil {
+		err := trans.MaxBytes(preset.MaxBytes.Bytes, preset.MaxBytes.Downscale)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if preset.Jpeg != nil {
+		err := trans.JpegOptions(preset.Jpeg.Progressive, preset.Jpeg.Subsample, preset.Jpeg.Trellis, preset.Jpeg.QuantTable)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if preset.Png != nil {
+		err := trans.PngOptions(preset.Png.Palette, preset.Png.Compression, preset.Png.Colors)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if preset.Webp != nil {
+		err := trans.WebpOptions(preset.Webp.NearLossless, preset.Webp.Effort, preset.Webp.SmartSubsample)
+		if err != nil {
+			return trans, err
+		}
+
+		if preset.Webp.Lossless {
+			trans.Lossless()
+		}
+	}
+
+	if preset.Avif != nil {
+		if preset.Avif.Speed != nil {
+			err := trans.Speed(*preset.Avif.Speed)
+			if err != nil {
+				return trans, err
+			}
+		}
+
+		if preset.Avif.Lossless {
+			trans.Lossless()
+		}
+	}
+
+	if preset.Jxl != nil {
+		if preset.Jxl.Effort != 0 {
+			err := trans.Effort(preset.Jxl.Effort)
+			if err != nil {
+				return trans, err
+			}
+		}
+
+		if preset.Jxl.Lossless {
+			trans.Lossless()
+		}
+	}
+
 	if filters.Blur != nil {
 		err := trans.Blur(filters.Blur.Sigma, filters.Blur.MinAmpl)
 		if err != nil {
@@ -140,20 +212,150 @@ func presetToTransform(preset config.Preset) (transforms.Transforms, error) {
 		}
 	}
 
+	if filters.Sharpen != nil {
+		err := trans.Sharpen(filters.Sharpen.Sigma, filters.Sharpen.Flat, filters.Sharpen.Jagged)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Modulate != nil {
+		err := trans.Modulate(filters.Modulate.Brightness, filters.Modulate.Contrast, filters.Modulate.Saturation, filters.Modulate.Hue)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Gamma != nil {
+		err := trans.Gamma(filters.Gamma.Exponent)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Tint != nil {
+		err := trans.Tint(filters.Tint.Color, filters.Tint.Shadow)
+		if err != nil {
+			return trans, err
+		}
+	}
+
 	if filters.Watermark != nil {
 		err := trans.Watermark(filters.Watermark.Image, filters.Watermark.Position, filters.Watermark.Opacity)
 		if err != nil {
 			return trans, err
 		}
+
+		if filters.Watermark.Scale != 0 || filters.Watermark.Margin != 0 || filters.Watermark.Tile {
+			err = trans.WatermarkLayout(filters.Watermark.Scale, filters.Watermark.Margin, filters.Watermark.Tile)
+			if err != nil {
+				return trans, err
+			}
+		}
+	}
+
+	if filters.Text != nil {
+		opacity := filters.Text.Opacity
+		if opacity == 0 {
+			opacity = 1
+		}
+		err := trans.Text(filters.Text.Text, filters.Text.Font, filters.Text.Size, filters.Text.Color, filters.Text.Position, opacity, filters.Text.DPI)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Extend != nil && filters.Pad != nil {
+		return trans, errors.New("extend and pad can't be used together")
+	}
+
+	extend := filters.Extend
+	if extend == nil {
+		extend = filters.Pad
+	}
+
+	if extend != nil {
+		err := trans.Extend(extend.Width, extend.Height, extend.Background, extend.Mode)
+		if err != nil {
+			return trans, err
+		}
 	}
 
 	if filters.Grayscale {
 		trans.Grayscale()
 	}
 
+	if filters.Info {
+		trans.Info()
+	}
+
 	if filters.Rotate != nil {
 		trans.Rotate(filters.Rotate.Angle)
 	}
 
+	if filters.Flip {
+		trans.Flip()
+	}
+
+	if filters.Flop {
+		trans.Flop()
+	}
+
+	if filters.Trim != nil {
+		err := trans.Trim(filters.Trim.Threshold, filters.Trim.Background)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Frame != nil {
+		err := trans.Frame(*filters.Frame)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Page != nil {
+		err := trans.Frame(*filters.Page)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.DPI != 0 {
+		err := trans.DPI(filters.DPI)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Zoom != nil {
+		err := trans.Zoom(filters.Zoom.Factor)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Metadata != nil {
+		err := trans.Metadata(filters.Metadata.Policy, filters.Metadata.Tags)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Mask != nil {
+		err := trans.Mask(filters.Mask.Shape, filters.Mask.Radius, filters.Mask.Image)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if filters.Palette != nil {
+		err := trans.Palette(filters.Palette.Colors)
+		if err != nil {
+			return trans, err
+		}
+	}
+
 	return trans, nil
 }
@@ -19,6 +19,9 @@ func decodeQuery(url *url.URL, bucketConfig config.Bucket, obj *FileObject) (str
 
 	var err error
 	obj.Transforms, err = queryToTransform(url.Query())
+	if err == nil {
+		err = CheckLimits(trans.Limits, &obj.Transforms)
+	}
 
 	if obj.HasTransform() {
 		parent := url.Path
@@ -64,10 +67,114 @@ func queryToTransform(query url.Values) (transforms.Transforms, error) {
 		}
 	}
 
+	if _, ok := query["speed"]; ok {
+		var speed int
+		speed, err = queryToInt(query, "speed")
+		if err != nil {
+			return trans, err
+		}
+		err = trans.Speed(speed)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if _, ok := query["effort"]; ok {
+		var effort int
+		effort, err = queryToInt(query, "effort")
+		if err != nil {
+			return trans, err
+		}
+		err = trans.Effort(effort)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	if _, ok := query["lossless"]; ok {
+		trans.Lossless()
+	}
+
+	err = queryToEncoderOptions(query, &trans)
+	if err != nil {
+		return trans, err
+	}
+
+	if _, ok := query["maxBytes"]; ok {
+		var maxBytes int
+		maxBytes, err = queryToInt(query, "maxBytes")
+		if err != nil {
+			return trans, errors.New("invalid maxBytes value: " + err.Error())
+		}
+		_, downscale := query["downscale"]
+		err = trans.MaxBytes(maxBytes, downscale)
+		if err != nil {
+			return trans, err
+		}
+	}
+
 	if _, ok := query["grayscale"]; ok {
 		trans.Grayscale()
 	}
 
+	_, noProfile := query["noProfile"]
+	if profile, ok := query["profile"]; ok || noProfile {
+		target := ""
+		if ok {
+			target = profile[0]
+		}
+		err = trans.ColorProfile(target, !noProfile)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	// page of document is extracted in the same way as frame of animation
+	for _, k := range []string{"frame", "page"} {
+		if _, ok := query[k]; ok {
+			var frame int
+			frame, err = queryToInt(query, k)
+			if err != nil {
+				return trans, errors.New("invalid " + k + " value: " + err.Error())
+			}
+			err = trans.Frame(frame)
+			if err != nil {
+				return trans, err
+			}
+		}
+	}
+
+	// dpi of text operation is parsed with operation
+	if _, ok := query["dpi"]; ok && !hasOperation(query, "text") {
+		var dpi int
+		dpi, err = queryToInt(query, "dpi")
+		if err != nil {
+			return trans, errors.New("invalid dpi value: " + err.Error())
+		}
+		err = trans.DPI(dpi)
+		if err != nil {
+			return trans, err
+		}
+	}
+
+	_, hasFx := query["fx"]
+	_, hasFy := query["fy"]
+	if hasFx || hasFy {
+		var fx, fy float64
+		fx, err = queryToFloat(query, "fx")
+		if err != nil {
+			return trans, err
+		}
+		fy, err = queryToFloat(query, "fy")
+		if err != nil {
+			return trans, err
+		}
+		err = trans.FocalPoint(fx, fy)
+		if err != nil {
+			return trans, err
+		}
+	}
+
 	return trans, err
 }
 
@@ -83,6 +190,106 @@ func queryToInt(q url.Values, k string) (int, error) {
 	return int(r), nil
 }
 
+func queryToFloat(q url.Values, k string) (float64, error) {
+	val := q.Get(k)
+	if val == "" {
+		return 0, errors.New("empty parameter value for " + k)
+	}
+	return strconv.ParseFloat(val, 64)
+}
+
+// queryToEncoderOptions parse options of jpeg, png and webp encoders
+func queryToEncoderOptions(query url.Values, trans *transforms.Transforms) error {
+	_, progressive := query["progressive"]
+	_, trellis := query["trellis"]
+	if progressive || trellis || query.Get("subsample") != "" || query.Get("quantTable") != "" {
+		quantTable, err := queryToOptionalInt(query, "quantTable")
+		if err != nil {
+			return err
+		}
+		err = trans.JpegOptions(progressive, query.Get("subsample"), trellis, quantTable)
+		if err != nil {
+			return err
+		}
+	}
+
+	// colors of palette operation are parsed with operation
+	colorsParam := ""
+	if !hasOperation(query, "palette") {
+		colorsParam = query.Get("colors")
+	}
+	_, palette := query["palette"]
+	if palette || colorsParam != "" || query.Get("compression") != "" {
+		compression, err := queryToOptionalInt(query, "compression")
+		if err != nil {
+			return err
+		}
+		colors := 0
+		if colorsParam != "" {
+			colors, err = queryToInt(query, "colors")
+			if err != nil {
+				return errors.New("invalid colors value: " + err.Error())
+			}
+		}
+		err = trans.PngOptions(palette, compression, colors)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, nearLossless := query["nearLossless"]
+	_, smartSubsample := query["smartSubsample"]
+	if nearLossless || smartSubsample || query.Get("webpEffort") != "" {
+		effort, err := queryToOptionalInt(query, "webpEffort")
+		if err != nil {
+			return err
+		}
+		err = trans.WebpOptions(nearLossless, effort, smartSubsample)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasOperation checks if query contains given operation
+func hasOperation(q url.Values, name string) bool {
+	for _, o := range q["operation"] {
+		if o == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryToOptionalFloat returns 0 for missing parameter
+func queryToOptionalFloat(q url.Values, k string) (float64, error) {
+	if q.Get(k) == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseFloat(q.Get(k), 64)
+	if err != nil {
+		return 0, errors.New("invalid " + k + " value: " + err.Error())
+	}
+	return v, nil
+}
+
+// queryToOptionalInt returns 0 for missing parameter
+func queryToOptionalInt(q url.Values, k string) (int, error) {
+	if q.Get(k) == "" {
+		return 0, nil
+	}
+
+	v, err := queryToInt(q, k)
+	if err != nil {
+		return 0, errors.New("invalid " + k + " value: " + err.Error())
+	}
+	return v, nil
+}
+
 // validatePositiveInt validates that an integer parameter is positive (> 0)
 func validatePositiveInt(value int, paramName string) error {
 	if value < 0 {
@@ -195,6 +402,15 @@ func parseOperation(query url.Values) (transforms.Transforms, error) {
 					if err != nil {
 						return trans, err
 					}
+				case "extend", "pad":
+					var w, h int
+					w, _ = queryToInt(query, "width")
+					h, _ = queryToInt(query, "height")
+
+					err = trans.Extend(w, h, query.Get("background"), query.Get("mode"))
+					if err != nil {
+						return trans, err
+					}
 				case "extract":
 					var w, h, t, l int
 					w, _ = queryToInt(query, "areaWith")
@@ -229,6 +445,49 @@ func parseOperation(query url.Values) (transforms.Transforms, error) {
 					if err != nil {
 						return trans, err
 					}
+					var scale float64
+					var margin int
+					if scaleStr := query.Get("scale"); scaleStr != "" {
+						scale, err = strconv.ParseFloat(scaleStr, 64)
+						if err != nil {
+							return trans, errors.New("invalid scale value: " + err.Error())
+						}
+					}
+					if query.Get("margin") != "" {
+						if margin, err = queryToInt(query, "margin"); err != nil {
+							return trans, errors.New("invalid margin value: " + err.Error())
+						}
+					}
+					_, tile := query["tile"]
+					if scale != 0 || margin != 0 || tile {
+						err = trans.WatermarkLayout(scale, margin, tile)
+						if err != nil {
+							return trans, err
+						}
+					}
+				case "text":
+					opacity := 1.
+					if opacityStr := query.Get("opacity"); opacityStr != "" {
+						opacity, err = strconv.ParseFloat(opacityStr, 32)
+						if err != nil {
+							return trans, errors.New("invalid opacity value: " + err.Error())
+						}
+					}
+					var size, dpi int
+					if query.Get("size") != "" {
+						if size, err = queryToInt(query, "size"); err != nil {
+							return trans, errors.New("invalid size value: " + err.Error())
+						}
+					}
+					if query.Get("dpi") != "" {
+						if dpi, err = queryToInt(query, "dpi"); err != nil {
+							return trans, errors.New("invalid dpi value: " + err.Error())
+						}
+					}
+					err = trans.Text(query.Get("text"), query.Get("font"), size, query.Get("color"), query.Get("position"), float32(opacity), dpi)
+					if err != nil {
+						return trans, err
+					}
 				case "blur":
 					var sigma, minAmpl float64
 					sigmaStr := query.Get("sigma")
@@ -252,6 +511,101 @@ func parseOperation(query url.Values) (transforms.Transforms, error) {
 					if err != nil {
 						return trans, err
 					}
+				case "sharpen":
+					var sigma, flat, jagged float64
+					if query.Get("sigma") == "" {
+						return trans, errors.New("sigma parameter is required for sharpen")
+					}
+					if sigma, err = queryToOptionalFloat(query, "sigma"); err != nil {
+						return trans, err
+					}
+					if flat, err = queryToOptionalFloat(query, "flat"); err != nil {
+						return trans, err
+					}
+					if jagged, err = queryToOptionalFloat(query, "jagged"); err != nil {
+						return trans, err
+					}
+					err = trans.Sharpen(sigma, flat, jagged)
+					if err != nil {
+						return trans, err
+					}
+				case "modulate":
+					var values [4]float64
+					for i, k := range []string{"brightness", "contrast", "saturation", "hue"} {
+						if values[i], err = queryToOptionalFloat(query, k); err != nil {
+							return trans, err
+						}
+					}
+					err = trans.Modulate(values[0], values[1], values[2], values[3])
+					if err != nil {
+						return trans, err
+					}
+				case "gamma":
+					var exponent float64
+					if query.Get("exponent") == "" {
+						return trans, errors.New("exponent parameter is required for gamma")
+					}
+					if exponent, err = queryToOptionalFloat(query, "exponent"); err != nil {
+						return trans, err
+					}
+					err = trans.Gamma(exponent)
+					if err != nil {
+						return trans, err
+					}
+				case "tint":
+					err = trans.Tint(query.Get("color"), query.Get("shadow"))
+					if err != nil {
+						return trans, err
+					}
+				case "info":
+					trans.Info()
+				case "mask":
+					var radius int
+					if query.Get("radius") != "" {
+						radius, err = queryToInt(query, "radius")
+						if err != nil {
+							return trans, errors.New("invalid radius value: " + err.Error())
+						}
+					}
+					err = trans.Mask(query.Get("shape"), radius, query.Get("image"))
+					if err != nil {
+						return trans, err
+					}
+				case "palette":
+					var colors int
+					if query.Get("colors") != "" {
+						colors, err = queryToInt(query, "colors")
+						if err != nil {
+							return trans, errors.New("invalid colors value: " + err.Error())
+						}
+					}
+					err = trans.Palette(colors)
+					if err != nil {
+						return trans, err
+					}
+				case "flip":
+					trans.Flip()
+				case "flop":
+					trans.Flop()
+				case "trim":
+					var threshold float64
+					if threshold, err = queryToOptionalFloat(query, "threshold"); err != nil {
+						return trans, err
+					}
+					err = trans.Trim(threshold, query.Get("background"))
+					if err != nil {
+						return trans, err
+					}
+				case "zoom":
+					var factor int
+					factor, err = queryToInt(query, "factor")
+					if err != nil {
+						return trans, errors.New("invalid factor value: " + err.Error())
+					}
+					err = trans.Zoom(factor)
+					if err != nil {
+						return trans, err
+					}
 				case "rotate":
 					var a int
 					angleStr := query.Get("angle")
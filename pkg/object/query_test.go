@@ -146,6 +146,9 @@ func TestQueryToTransform_CropValidation(t *testing.T) {
 		{"valid crop", "operation=crop&width=100&height=200", false, ""},
 		{"invalid negative width", "operation=crop&width=-100&height=200", true, "width and height cannot be negative"},
 		{"invalid negative height", "operation=crop&width=100&height=-200", true, "width and height cannot be negative"},
+		{"valid focal point", "operation=crop&width=100&height=200&fx=0.3&fy=120", false, ""},
+		{"invalid focal point", "operation=crop&width=100&height=200&fx=-0.3&fy=0.5", true, "invalid focal point"},
+		{"missing focal y", "operation=crop&width=100&height=200&fx=0.3", true, "empty parameter value for fy"},
 	}
 
 	for _, tt := range tests {
@@ -209,6 +212,9 @@ func TestQueryToTransform_WatermarkValidation(t *testing.T) {
 		{"invalid opacity > 1", "operation=watermark&image=test.jpg&position=top-left&opacity=1.1", true, "opacity must be between 0 and 1"},
 		{"invalid opacity text", "operation=watermark&image=test.jpg&position=top-left&opacity=invalid", true, "invalid opacity value"},
 		{"missing opacity", "operation=watermark&image=test.jpg&position=top-left", true, "opacity parameter is required"},
+		{"valid layout", "operation=watermark&image=test.jpg&position=top-left&opacity=1&scale=0.2&margin=10&tile=1", false, ""},
+		{"invalid scale", "operation=watermark&image=test.jpg&position=top-left&opacity=1&scale=2", true, "watermark scale must be between 0 and 1"},
+		{"invalid margin", "operation=watermark&image=test.jpg&position=top-left&opacity=1&margin=abc", true, "invalid margin value"},
 	}
 
 	for _, tt := range tests {
@@ -226,6 +232,310 @@ func TestQueryToTransform_WatermarkValidation(t *testing.T) {
 	}
 }
 
+func TestQueryToTransform_TextValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		errMsg  string
+	}{
+		{"valid text", "operation=text&text=hello&position=bottom-center", false, ""},
+		{"valid text with options", "operation=text&text=hello&position=top-left&font=serif&size=12&color=fff&opacity=0.5&dpi=144", false, ""},
+		{"missing position", "operation=text&text=hello", true, "missing required params text or position"},
+		{"invalid size", "operation=text&text=hello&position=top-left&size=abc", true, "invalid size value"},
+		{"invalid opacity", "operation=text&text=hello&position=top-left&opacity=abc", true, "invalid opacity value"},
+		{"invalid color", "operation=text&text=hello&position=top-left&color=nocolor", true, "invalid color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("http://example.com/image.jpg?" + tt.query)
+			trans, err := queryToTransform(u.Query())
+			if tt.wantErr {
+				require.NotNil(t, err, "should return error for %s", tt.query)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.Nil(t, err, "should not return error for %s", tt.query)
+				assert.True(t, trans.NotEmpty)
+			}
+		})
+	}
+}
+
+func TestQueryToTransform_ExtendValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		errMsg  string
+	}{
+		{"valid extend", "operation=extend&width=300&height=200", false, ""},
+		{"valid transparent extend", "operation=extend&width=300&height=200&background=transparent", false, ""},
+		{"valid blur extend", "operation=extend&width=300&height=200&mode=blur", false, ""},
+		{"valid pad", "operation=pad&width=300&height=200", false, ""},
+		{"pad missing height", "operation=pad&width=300", true, "extend width and height must be positive"},
+		{"missing height", "operation=extend&width=300", true, "extend width and height must be positive"},
+		{"invalid mode", "operation=extend&width=300&height=200&mode=mirror", true, "unknown extend mode"},
+		{"invalid background", "operation=extend&width=300&height=200&background=nocolor", true, "invalid color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("http://example.com/image.jpg?" + tt.query)
+			trans, err := queryToTransform(u.Query())
+			if tt.wantErr {
+				require.NotNil(t, err, "should return error for %s", tt.query)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.Nil(t, err, "should not return error for %s", tt.query)
+				assert.True(t, trans.NotEmpty)
+			}
+		})
+	}
+}
+
+func TestQueryToTransform_AdjustmentsValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		errMsg  string
+	}{
+		{"valid sharpen", "operation=sharpen&sigma=1.5&flat=1&jagged=2", false, ""},
+		{"missing sharpen sigma", "operation=sharpen&flat=1", true, "sigma parameter is required for sharpen"},
+		{"invalid sharpen flat", "operation=sharpen&sigma=1&flat=abc", true, "invalid flat value"},
+		{"valid modulate", "operation=modulate&brightness=1.1&saturation=0.5&hue=180", false, ""},
+		{"invalid modulate", "operation=modulate&contrast=-1", true, "cannot be negative"},
+		{"valid gamma", "operation=gamma&exponent=2.2", false, ""},
+		{"missing gamma exponent", "operation=gamma", true, "exponent parameter is required for gamma"},
+		{"valid tint", "operation=tint&color=ff0000&shadow=000033", false, ""},
+		{"invalid tint", "operation=tint", true, "invalid color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("http://example.com/image.jpg?" + tt.query)
+			trans, err := queryToTransform(u.Query())
+			if tt.wantErr {
+				require.NotNil(t, err, "should return error for %s", tt.query)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.Nil(t, err, "should not return error for %s", tt.query)
+				assert.True(t, trans.NotEmpty)
+			}
+		})
+	}
+}
+
+func TestQueryToTransform_MirrorTrimZoom(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+		errMsg  string
+	}{
+		{"valid flip", "operation=flip", false, ""},
+		{"valid flop", "operation=flop", false, ""},
+		{"valid trim", "operation=trim", false, ""},
+		{"valid trim with options", "operation=trim&threshold=20&background=000", false, ""},
+		{"invalid trim threshold", "operation=trim&threshold=abc", true, "invalid threshold value"},
+		{"invalid trim background", "operation=trim&background=nocolor", true, "invalid color"},
+		{"valid zoom", "operation=zoom&factor=2", false, ""},
+		{"missing zoom factor", "operation=zoom", true, "invalid factor value"},
+		{"too big zoom factor", "operation=zoom&factor=20", true, "zoom must be between"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse("http://example.com/image.jpg?" + tt.query)
+			trans, err := queryToTransform(u.Query())
+			if tt.wantErr {
+				require.NotNil(t, err, "should return error for %s", tt.query)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.Nil(t, err, "should not return error for %s", tt.query)
+				assert.True(t, trans.NotEmpty)
+			}
+		})
+	}
+}
+
+func TestQueryToTransform_Frame(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"frame": []string{"2"}, "width": []string{"100"}})
+	require.Nil(t, err)
+	frame, ok := trans.FrameIndex()
+	assert.True(t, ok)
+	assert.Equal(t, 2, frame)
+
+	_, err = queryToTransform(url.Values{"frame": []string{"abc"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"frame": []string{"-1"}})
+	assert.NotNil(t, err)
+}
+
+func TestQueryToTransform_PageDPI(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"page": []string{"3"}, "dpi": []string{"150"}})
+	require.Nil(t, err)
+	page, ok := trans.FrameIndex()
+	assert.True(t, ok)
+	assert.Equal(t, 3, page)
+	assert.Equal(t, 150, trans.DocumentDPI())
+
+	_, err = queryToTransform(url.Values{"dpi": []string{"1000"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"page": []string{"abc"}})
+	assert.NotNil(t, err)
+
+	// dpi of text is not resolution of document
+	trans, err = queryToTransform(url.Values{"operation": []string{"text"}, "text": []string{"a"}, "position": []string{"top-left"}, "dpi": []string{"1000"}})
+	require.Nil(t, err)
+	assert.Equal(t, 0, trans.DocumentDPI())
+}
+
+func TestQueryToTransform_Info(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"operation": []string{"info"}})
+	require.Nil(t, err)
+	assert.True(t, trans.HasInfo())
+	assert.True(t, trans.NotEmpty)
+}
+
+func TestQueryToTransform_Mask(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"operation": []string{"mask"}, "shape": []string{"rounded"}, "radius": []string{"12"}})
+	require.Nil(t, err)
+	mask, ok, err := trans.MaskOptions()
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "rounded", mask.Shape)
+	assert.Equal(t, 12, mask.Radius)
+
+	trans, err = queryToTransform(url.Values{"operation": []string{"mask"}, "shape": []string{"circle"}})
+	require.Nil(t, err)
+	assert.Contains(t, trans.Summary().Operations, "mask")
+
+	_, err = queryToTransform(url.Values{"operation": []string{"mask"}, "shape": []string{"rounded"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"operation": []string{"mask"}, "shape": []string{"rounded"}, "radius": []string{"a"}})
+	assert.NotNil(t, err)
+}
+
+func TestQueryToTransform_ColorProfile(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"profile": []string{"p3"}})
+	require.Nil(t, err)
+	profile, ok, err := trans.ColorProfileOptions("srgb")
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "p3", profile.Target)
+	assert.True(t, trans.NotEmpty)
+
+	trans, err = queryToTransform(url.Values{"noProfile": []string{""}})
+	require.Nil(t, err)
+	_, ok, _ = trans.ColorProfileOptions("srgb")
+	assert.False(t, ok)
+	assert.True(t, trans.NotEmpty)
+
+	_, err = queryToTransform(url.Values{"profile": []string{"adobe"}})
+	assert.NotNil(t, err)
+}
+
+func TestQueryToTransform_EncoderOptions(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"subsample": []string{"off"}, "trellis": []string{""}, "quantTable": []string{"3"}})
+	require.Nil(t, err)
+	enc, ok := trans.Encoder("jpeg")
+	assert.True(t, ok)
+	assert.Equal(t, "off", enc.Subsample)
+	assert.True(t, enc.Trellis)
+	assert.Equal(t, 3, enc.QuantTable)
+
+	trans, err = queryToTransform(url.Values{"colors": []string{"16"}, "compression": []string{"9"}})
+	require.Nil(t, err)
+	enc, ok = trans.Encoder("png")
+	assert.True(t, ok)
+	assert.True(t, enc.Palette)
+	assert.Equal(t, 16, enc.Colors)
+	assert.Equal(t, 9, enc.Compression)
+
+	trans, err = queryToTransform(url.Values{"format": []string{"webp"}, "nearLossless": []string{""}, "webpEffort": []string{"6"}})
+	require.Nil(t, err)
+	enc, ok = trans.Encoder("jpeg")
+	assert.True(t, ok)
+	assert.Equal(t, "webp", enc.Format)
+	assert.True(t, enc.NearLossless)
+	assert.Equal(t, 6, enc.Effort)
+
+	// colors of palette operation are not png options
+	trans, err = queryToTransform(url.Values{"operation": []string{"palette"}, "colors": []string{"3"}})
+	require.Nil(t, err)
+	_, ok = trans.Encoder("png")
+	assert.False(t, ok)
+
+	_, err = queryToTransform(url.Values{"quantTable": []string{"a"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"subsample": []string{"422"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"webpEffort": []string{"9"}})
+	assert.NotNil(t, err)
+}
+
+func TestQueryToTransform_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"maxBytes": []string{"20000"}, "downscale": []string{""}})
+	require.Nil(t, err)
+	enc, ok := trans.Encoder("jpeg")
+	assert.True(t, ok)
+	assert.Equal(t, 20000, enc.MaxBytes)
+	assert.True(t, enc.Downscale)
+
+	_, err = queryToTransform(url.Values{"maxBytes": []string{"0"}})
+	assert.NotNil(t, err)
+
+	_, err = queryToTransform(url.Values{"maxBytes": []string{"a"}})
+	assert.NotNil(t, err)
+}
+
+func TestQueryToTransform_Palette(t *testing.T) {
+	t.Parallel()
+
+	trans, err := queryToTransform(url.Values{"operation": []string{"palette"}, "colors": []string{"8"}})
+	require.Nil(t, err)
+	colors, ok := trans.PaletteColors()
+	assert.True(t, ok)
+	assert.Equal(t, 8, colors)
+
+	trans, err = queryToTransform(url.Values{"operation": []string{"palette"}})
+	require.Nil(t, err)
+	colors, _ = trans.PaletteColors()
+	assert.Equal(t, 5, colors)
+
+	_, err = queryToTransform(url.Values{"operation": []string{"palette"}, "colors": []string{"100"}})
+	assert.NotNil(t, err)
+}
+
 func TestQueryToTransform_BlurValidation(t *testing.T) {
 	t.Parallel()
 
@@ -302,6 +612,11 @@ func TestQueryToTransform_FormatValidation(t *testing.T) {
 		{"valid format jpeg", "width=100&format=jpeg", false, ""},
 		{"valid format webp", "width=100&format=webp", false, ""},
 		{"valid format png", "width=100&format=png", false, ""},
+		{"valid format avif", "width=100&format=avif&speed=6", false, ""},
+		{"valid format jxl", "width=100&format=jxl&effort=7&lossless=1", false, ""},
+		{"invalid avif speed", "width=100&format=avif&speed=10", true, "speed must be between 0 and 8"},
+		{"invalid jxl effort", "width=100&format=jxl&effort=0", true, "effort must be between 1 and 9"},
+		{"invalid speed value", "width=100&format=avif&speed=fast", true, "invalid syntax"},
 		{"invalid format", "width=100&format=invalid_format", true, "Unknown format"},
 	}
 
@@ -0,0 +1,109 @@
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aldor007/mort/pkg/config"
+)
+
+// ErrInvalidSignature is returned when signed bucket receives URL without valid signature
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignURL returns rawURL with signature query parameter for given signing config
+// if expires is not zero, expiry parameter is added to URL before signing
+func SignURL(rawURL string, signing config.Signing, expires time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	if signing.Secret == "" {
+		return "", errors.New("empty signing secret")
+	}
+
+	if signing.Param == "" {
+		signing.Param = "sig"
+	}
+
+	query := u.Query()
+	query.Del(signing.Param)
+	if !expires.IsZero() {
+		if signing.ExpiresParam == "" {
+			return "", errors.New("signing config has no expiresParam")
+		}
+		query.Set(signing.ExpiresParam, strconv.FormatInt(expires.Unix(), 10))
+	}
+
+	query.Set(signing.Param, signature(u.Path, query, signing))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// verifySignature checks if URL is signed with secret from signing config and that it isn't expired
+func verifySignature(u *url.URL, signing *config.Signing) error {
+	query := u.Query()
+	sig := query.Get(signing.Param)
+	if sig == "" {
+		return fmt.Errorf("%w: missing %s parameter", ErrInvalidSignature, signing.Param)
+	}
+
+	query.Del(signing.Param)
+	if !hmac.Equal([]byte(sig), []byte(signature(u.Path, query, *signing))) {
+		return ErrInvalidSignature
+	}
+
+	if signing.ExpiresParam != "" && query.Get(signing.ExpiresParam) != "" {
+		expires, err := strconv.ParseInt(query.Get(signing.ExpiresParam), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid %s parameter", ErrInvalidSignature, signing.ExpiresParam)
+		}
+
+		if time.Now().Unix() > expires {
+			return fmt.Errorf("%w: URL expired", ErrInvalidSignature)
+		}
+	}
+
+	return nil
+}
+
+// requiresSignature returns true for URLs which would be transformed by bucket parser
+// requests for originals and S3 API requests (query without transform parameters) don't need signature
+func requiresSignature(u *url.URL, transform *config.Transform, signing *config.Signing) bool {
+	if transform.PathRegexp != nil && transform.PathRegexp.MatchString(u.Path) {
+		return true
+	}
+
+	if u.RawQuery == "" {
+		return false
+	}
+
+	switch transform.Kind {
+	case "query", "presets-query":
+		query := u.Query()
+		query.Del(signing.Param)
+		query.Del(signing.ExpiresParam)
+		trans, err := queryToTransform(query)
+		return err != nil || trans.NotEmpty
+	case "tengo":
+		// script can use any query parameter
+		return true
+	}
+
+	return false
+}
+
+// signature returns HMAC-SHA256 of path and sorted query encoded with url safe base64
+func signature(path string, query url.Values, signing config.Signing) string {
+	mac := hmac.New(sha256.New, []byte(signing.Secret))
+	mac.Write([]byte(path))
+	mac.Write([]byte{'?'})
+	mac.Write([]byte(query.Encode()))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
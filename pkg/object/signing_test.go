@@ -0,0 +1,119 @@
+package object
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSigningConfig(t *testing.T) (*config.Config, config.Signing) {
+	mortConfig := config.Config{}
+	err := mortConfig.Load("testdata/bucket-transform-signing.yml")
+	require.Nil(t, err)
+
+	signing := *mortConfig.Buckets["bucket"].Transform.Signing
+	assert.Equal(t, "sig", signing.Param)
+	return &mortConfig, signing
+}
+
+func TestSignedURL(t *testing.T) {
+	mortConfig, signing := loadSigningConfig(t)
+
+	signed, err := SignURL("/bucket/parent.jpg?width=100&operation=resize", signing, time.Time{})
+	require.Nil(t, err)
+
+	u, _ := url.Parse(signed)
+	obj, err := NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+	assert.True(t, obj.HasTransform())
+
+	u, _ = url.Parse("/bucket/parent.jpg?operation=resize&width=100&sig=" + u.Query().Get("sig"))
+	_, err = NewFileObject(u, mortConfig)
+	assert.Nil(t, err, "query order shouldn't change signature")
+}
+
+func TestSignedURLInvalid(t *testing.T) {
+	mortConfig, signing := loadSigningConfig(t)
+
+	signed, err := SignURL("/bucket/parent.jpg?operation=resize&width=100", signing, time.Time{})
+	require.Nil(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"unsigned", "/bucket/parent.jpg?operation=resize&width=100"},
+		{"tampered", signed + "0"},
+		{"tampered query", signed + "&height=4999"},
+		{"tampered path", "/bucket/other.jpg?" + signed[len("/bucket/parent.jpg?"):]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			_, err := NewFileObject(u, mortConfig)
+			require.NotNil(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidSignature))
+		})
+	}
+}
+
+func TestSignedURLExpires(t *testing.T) {
+	mortConfig, signing := loadSigningConfig(t)
+
+	signed, err := SignURL("/bucket/parent.jpg?operation=resize&width=100", signing, time.Now().Add(time.Hour))
+	require.Nil(t, err)
+	u, _ := url.Parse(signed)
+	assert.NotEmpty(t, u.Query().Get("expires"))
+	_, err = NewFileObject(u, mortConfig)
+	assert.Nil(t, err)
+
+	signed, err = SignURL("/bucket/parent.jpg?operation=resize&width=100", signing, time.Now().Add(-time.Hour))
+	require.Nil(t, err)
+	u, _ = url.Parse(signed)
+	_, err = NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+}
+
+func TestSignedURLOriginal(t *testing.T) {
+	mortConfig, _ := loadSigningConfig(t)
+
+	u, _ := url.Parse("/bucket/parent.jpg")
+	obj, err := NewFileObject(u, mortConfig)
+	assert.Nil(t, err)
+	assert.False(t, obj.HasTransform())
+}
+
+func TestSignedURLNotTransformQuery(t *testing.T) {
+	mortConfig, _ := loadSigningConfig(t)
+
+	tests := []string{
+		"/bucket?list-type=2&prefix=dir%2F&max-keys=100",
+		"/bucket/parent.jpg?X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=key&X-Amz-Expires=60&X-Amz-Signature=abc",
+		"/bucket/parent.jpg?v=123",
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt)
+		obj, err := NewFileObject(u, mortConfig)
+		assert.Nil(t, err, tt)
+		assert.False(t, obj.HasTransform(), tt)
+	}
+
+	u, _ := url.Parse("/bucket/parent.jpg?v=123&width=100")
+	_, err := NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+}
+
+func TestSignURLErrors(t *testing.T) {
+	_, err := SignURL("/bucket/parent.jpg", config.Signing{}, time.Time{})
+	assert.NotNil(t, err)
+
+	_, err = SignURL("/bucket/parent.jpg", config.Signing{Secret: "secret"}, time.Now())
+	assert.NotNil(t, err)
+}
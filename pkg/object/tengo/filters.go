@@ -100,6 +100,26 @@ func (o *Filters) IndexGet(index tengoLib.Object) (val tengoLib.Object, err erro
 			internalMap["height"] = &tengoLib.Int{Value: int64(o.Value.ResizeCropAuto.Height)}
 			val = &tengoLib.ImmutableMap{Value: internalMap}
 		}
+	case "focalPoint":
+		if o.Value.FocalPoint != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["x"] = &tengoLib.Float{Value: o.Value.FocalPoint.X}
+			internalMap["y"] = &tengoLib.Float{Value: o.Value.FocalPoint.Y}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "extend", "pad":
+		extend := o.Value.Extend
+		if strIdx == "pad" {
+			extend = o.Value.Pad
+		}
+		if extend != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["width"] = &tengoLib.Int{Value: int64(extend.Width)}
+			internalMap["height"] = &tengoLib.Int{Value: int64(extend.Height)}
+			internalMap["background"] = &tengoLib.String{Value: extend.Background}
+			internalMap["mode"] = &tengoLib.String{Value: extend.Mode}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
 	case "blur":
 		if o.Value.Blur != nil {
 			internalMap := make(map[string]tengoLib.Object)
@@ -107,6 +127,36 @@ func (o *Filters) IndexGet(index tengoLib.Object) (val tengoLib.Object, err erro
 			internalMap["minAmpl"] = &tengoLib.Float{Value: o.Value.Blur.MinAmpl}
 			val = &tengoLib.ImmutableMap{Value: internalMap}
 		}
+	case "sharpen":
+		if o.Value.Sharpen != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["sigma"] = &tengoLib.Float{Value: o.Value.Sharpen.Sigma}
+			internalMap["flat"] = &tengoLib.Float{Value: o.Value.Sharpen.Flat}
+			internalMap["jagged"] = &tengoLib.Float{Value: o.Value.Sharpen.Jagged}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "modulate":
+		if o.Value.Modulate != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["brightness"] = &tengoLib.Float{Value: o.Value.Modulate.Brightness}
+			internalMap["contrast"] = &tengoLib.Float{Value: o.Value.Modulate.Contrast}
+			internalMap["saturation"] = &tengoLib.Float{Value: o.Value.Modulate.Saturation}
+			internalMap["hue"] = &tengoLib.Float{Value: o.Value.Modulate.Hue}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "gamma":
+		if o.Value.Gamma != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["exponent"] = &tengoLib.Float{Value: o.Value.Gamma.Exponent}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "tint":
+		if o.Value.Tint != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["color"] = &tengoLib.String{Value: o.Value.Tint.Color}
+			internalMap["shadow"] = &tengoLib.String{Value: o.Value.Tint.Shadow}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
 	case "rotate":
 		if o.Value.Rotate != nil {
 			internalMap := make(map[string]tengoLib.Object)
@@ -119,6 +169,25 @@ func (o *Filters) IndexGet(index tengoLib.Object) (val tengoLib.Object, err erro
 			internalMap["image"] = &tengoLib.String{Value: o.Value.Watermark.Image}
 			internalMap["position"] = &tengoLib.String{Value: o.Value.Watermark.Position}
 			internalMap["opacity"] = &tengoLib.Float{Value: float64(o.Value.Watermark.Opacity)}
+			internalMap["scale"] = &tengoLib.Float{Value: o.Value.Watermark.Scale}
+			internalMap["margin"] = &tengoLib.Int{Value: int64(o.Value.Watermark.Margin)}
+			if o.Value.Watermark.Tile {
+				internalMap["tile"] = tengoLib.TrueValue
+			} else {
+				internalMap["tile"] = tengoLib.FalseValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "text":
+		if o.Value.Text != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["text"] = &tengoLib.String{Value: o.Value.Text.Text}
+			internalMap["font"] = &tengoLib.String{Value: o.Value.Text.Font}
+			internalMap["size"] = &tengoLib.Int{Value: int64(o.Value.Text.Size)}
+			internalMap["color"] = &tengoLib.String{Value: o.Value.Text.Color}
+			internalMap["position"] = &tengoLib.String{Value: o.Value.Text.Position}
+			internalMap["opacity"] = &tengoLib.Float{Value: float64(o.Value.Text.Opacity)}
+			internalMap["dpi"] = &tengoLib.Int{Value: int64(o.Value.Text.DPI)}
 			val = &tengoLib.ImmutableMap{Value: internalMap}
 		}
 	case "interlace":
@@ -133,12 +202,88 @@ func (o *Filters) IndexGet(index tengoLib.Object) (val tengoLib.Object, err erro
 		} else {
 			val = tengoLib.FalseValue
 		}
+	case "flip":
+		if o.Value.Flip {
+			val = tengoLib.TrueValue
+		} else {
+			val = tengoLib.FalseValue
+		}
+	case "flop":
+		if o.Value.Flop {
+			val = tengoLib.TrueValue
+		} else {
+			val = tengoLib.FalseValue
+		}
+	case "trim":
+		if o.Value.Trim != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["threshold"] = &tengoLib.Float{Value: o.Value.Trim.Threshold}
+			internalMap["background"] = &tengoLib.String{Value: o.Value.Trim.Background}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "frame":
+		if o.Value.Frame != nil {
+			val = &tengoLib.Int{Value: int64(*o.Value.Frame)}
+		}
+	case "page":
+		if o.Value.Page != nil {
+			val = &tengoLib.Int{Value: int64(*o.Value.Page)}
+		}
+	case "dpi":
+		val = &tengoLib.Int{Value: int64(o.Value.DPI)}
+	case "zoom":
+		if o.Value.Zoom != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["factor"] = &tengoLib.Int{Value: int64(o.Value.Zoom.Factor)}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "metadata":
+		if o.Value.Metadata != nil {
+			tags := make([]tengoLib.Object, 0, len(o.Value.Metadata.Tags))
+			for _, tag := range o.Value.Metadata.Tags {
+				tags = append(tags, &tengoLib.String{Value: tag})
+			}
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["policy"] = &tengoLib.String{Value: o.Value.Metadata.Policy}
+			internalMap["tags"] = &tengoLib.ImmutableArray{Value: tags}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "colorProfile":
+		if o.Value.ColorProfile != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["target"] = &tengoLib.String{Value: o.Value.ColorProfile.Target}
+			internalMap["embed"] = tengoLib.TrueValue
+			if o.Value.ColorProfile.Embed != nil && !*o.Value.ColorProfile.Embed {
+				internalMap["embed"] = tengoLib.FalseValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "mask":
+		if o.Value.Mask != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["shape"] = &tengoLib.String{Value: o.Value.Mask.Shape}
+			internalMap["radius"] = &tengoLib.Int{Value: int64(o.Value.Mask.Radius)}
+			internalMap["image"] = &tengoLib.String{Value: o.Value.Mask.Image}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "palette":
+		if o.Value.Palette != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["colors"] = &tengoLib.Int{Value: int64(o.Value.Palette.Colors)}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
 	case "grayscale":
 		if o.Value.Grayscale {
 			val = tengoLib.TrueValue
 		} else {
 			val = tengoLib.FalseValue
 		}
+	case "info":
+		if o.Value.Info {
+			val = tengoLib.TrueValue
+		} else {
+			val = tengoLib.FalseValue
+		}
 	case "strip":
 		if o.Value.Strip {
 			val = tengoLib.TrueValue
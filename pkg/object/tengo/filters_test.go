@@ -100,10 +100,14 @@ func TestFiltersTengoGet(t *testing.T) {
 			Image    string  "yaml:\"image\""
 			Position string  "yaml:\"position\""
 			Opacity  float32 "yaml:\"opacity\""
+			Scale    float64 "yaml:\"scale\""
+			Margin   int     "yaml:\"margin\""
+			Tile     bool    "yaml:\"tile\""
 		}{
 			Image:    "aaa.png",
 			Position: "top-left",
 			Opacity:  2.2,
+			Margin:   10,
 		},
 		Rotate: &struct {
 			Angle int "yaml:\"angle\""
@@ -114,6 +118,15 @@ func TestFiltersTengoGet(t *testing.T) {
 		Grayscale:  true,
 		AutoRotate: true,
 		Strip:      true,
+		Metadata:   &config.MetadataPolicy{Policy: "allowlist", Tags: []string{"Artist", "Copyright"}},
+	}
+	embed := false
+	c.ColorProfile = &struct {
+		Target string "yaml:\"target\""
+		Embed  *bool  "yaml:\"embed\""
+	}{
+		Target: "p3",
+		Embed:  &embed,
 	}
 
 	tengoObject := tengo.Filters{Value: c}
@@ -176,6 +189,9 @@ func TestFiltersTengoGet(t *testing.T) {
 	widthTengo, _ = res.IndexGet(&tengoLib.String{Value: "image"})
 	img, _ := tengoLib.ToString(widthTengo)
 	assert.Equal(t, img, "aaa.png")
+	widthTengo, _ = res.IndexGet(&tengoLib.String{Value: "margin"})
+	width, _ = tengoLib.ToInt(widthTengo)
+	assert.Equal(t, width, 10)
 
 	res, err = tengoObject.IndexGet(&tengoLib.String{Value: "interlace"})
 	assert.Nil(t, err)
@@ -193,4 +209,21 @@ func TestFiltersTengoGet(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, res, tengoLib.TrueValue)
 
+	res, err = tengoObject.IndexGet(&tengoLib.String{Value: "metadata"})
+	assert.Nil(t, err)
+	assert.Equal(t, res.TypeName(), "immutable-map")
+	policyTengo, _ := res.IndexGet(&tengoLib.String{Value: "policy"})
+	policy, _ := tengoLib.ToString(policyTengo)
+	assert.Equal(t, policy, "allowlist")
+	tagsTengo, _ := res.IndexGet(&tengoLib.String{Value: "tags"})
+	assert.Len(t, tagsTengo.(*tengoLib.ImmutableArray).Value, 2)
+
+	res, err = tengoObject.IndexGet(&tengoLib.String{Value: "colorProfile"})
+	assert.Nil(t, err)
+	targetTengo, _ := res.IndexGet(&tengoLib.String{Value: "target"})
+	target, _ := tengoLib.ToString(targetTengo)
+	assert.Equal(t, target, "p3")
+	embedTengo, _ := res.IndexGet(&tengoLib.String{Value: "embed"})
+	assert.Equal(t, embedTengo, tengoLib.FalseValue)
+
 }
@@ -62,6 +62,82 @@ func (o *Preset) IndexGet(index tengoLib.Object) (val tengoLib.Object, err error
 		val = &tengoLib.String{Value: o.Value.Format}
 	case "filters":
 		val = &Filters{Value: o.Value.Filters}
+	case "jpeg":
+		if o.Value.Jpeg != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["progressive"] = tengoLib.FalseValue
+			if o.Value.Jpeg.Progressive {
+				internalMap["progressive"] = tengoLib.TrueValue
+			}
+			internalMap["subsample"] = &tengoLib.String{Value: o.Value.Jpeg.Subsample}
+			internalMap["trellis"] = tengoLib.FalseValue
+			if o.Value.Jpeg.Trellis {
+				internalMap["trellis"] = tengoLib.TrueValue
+			}
+			internalMap["quantTable"] = &tengoLib.Int{Value: int64(o.Value.Jpeg.QuantTable)}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "png":
+		if o.Value.Png != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["palette"] = tengoLib.FalseValue
+			if o.Value.Png.Palette {
+				internalMap["palette"] = tengoLib.TrueValue
+			}
+			internalMap["compression"] = &tengoLib.Int{Value: int64(o.Value.Png.Compression)}
+			internalMap["colors"] = &tengoLib.Int{Value: int64(o.Value.Png.Colors)}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "webp":
+		if o.Value.Webp != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["lossless"] = tengoLib.FalseValue
+			if o.Value.Webp.Lossless {
+				internalMap["lossless"] = tengoLib.TrueValue
+			}
+			internalMap["nearLossless"] = tengoLib.FalseValue
+			if o.Value.Webp.NearLossless {
+				internalMap["nearLossless"] = tengoLib.TrueValue
+			}
+			internalMap["effort"] = &tengoLib.Int{Value: int64(o.Value.Webp.Effort)}
+			internalMap["smartSubsample"] = tengoLib.FalseValue
+			if o.Value.Webp.SmartSubsample {
+				internalMap["smartSubsample"] = tengoLib.TrueValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "maxBytes":
+		if o.Value.MaxBytes != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["bytes"] = &tengoLib.Int{Value: int64(o.Value.MaxBytes.Bytes)}
+			internalMap["downscale"] = tengoLib.FalseValue
+			if o.Value.MaxBytes.Downscale {
+				internalMap["downscale"] = tengoLib.TrueValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "avif":
+		if o.Value.Avif != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			if o.Value.Avif.Speed != nil {
+				internalMap["speed"] = &tengoLib.Int{Value: int64(*o.Value.Avif.Speed)}
+			}
+			internalMap["lossless"] = tengoLib.FalseValue
+			if o.Value.Avif.Lossless {
+				internalMap["lossless"] = tengoLib.TrueValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
+	case "jxl":
+		if o.Value.Jxl != nil {
+			internalMap := make(map[string]tengoLib.Object)
+			internalMap["effort"] = &tengoLib.Int{Value: int64(o.Value.Jxl.Effort)}
+			internalMap["lossless"] = tengoLib.FalseValue
+			if o.Value.Jxl.Lossless {
+				internalMap["lossless"] = tengoLib.TrueValue
+			}
+			val = &tengoLib.ImmutableMap{Value: internalMap}
+		}
 	}
 
 	return val, nil
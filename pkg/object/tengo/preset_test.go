@@ -52,3 +52,51 @@ func TestPresetTengoGet(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, v.TypeName(), "Filters-object")
 }
+
+func TestPresetTengoGetEncoderOptions(t *testing.T) {
+	c := config.Preset{
+		Jpeg: &config.JpegOptions{Progressive: true, Subsample: "off"},
+		Png:  &config.PngOptions{Palette: true, Colors: 64},
+		Webp: &config.WebpOptions{NearLossless: true, Effort: 5},
+	}
+
+	tengoObject := tengo.Preset{Value: c}
+
+	v, err := tengoObject.IndexGet(&tengoLib.String{Value: "jpeg"})
+	assert.Nil(t, err)
+	progressive, _ := v.IndexGet(&tengoLib.String{Value: "progressive"})
+	assert.Equal(t, progressive, tengoLib.TrueValue)
+	subsampleTengo, _ := v.IndexGet(&tengoLib.String{Value: "subsample"})
+	subsample, _ := tengoLib.ToString(subsampleTengo)
+	assert.Equal(t, subsample, "off")
+
+	v, err = tengoObject.IndexGet(&tengoLib.String{Value: "png"})
+	assert.Nil(t, err)
+	colorsTengo, _ := v.IndexGet(&tengoLib.String{Value: "colors"})
+	colors, _ := tengoLib.ToInt(colorsTengo)
+	assert.Equal(t, colors, 64)
+
+	v, err = tengoObject.IndexGet(&tengoLib.String{Value: "webp"})
+	assert.Nil(t, err)
+	effortTengo, _ := v.IndexGet(&tengoLib.String{Value: "effort"})
+	effort, _ := tengoLib.ToInt(effortTengo)
+	assert.Equal(t, effort, 5)
+	nearLossless, _ := v.IndexGet(&tengoLib.String{Value: "nearLossless"})
+	assert.Equal(t, nearLossless, tengoLib.TrueValue)
+}
+
+func TestPresetTengoGetMaxBytes(t *testing.T) {
+	c := config.Preset{
+		MaxBytes: &config.MaxBytesOptions{Bytes: 20000, Downscale: true},
+	}
+
+	tengoObject := tengo.Preset{Value: c}
+
+	v, err := tengoObject.IndexGet(&tengoLib.String{Value: "maxBytes"})
+	assert.Nil(t, err)
+	bytesTengo, _ := v.IndexGet(&tengoLib.String{Value: "bytes"})
+	bytes, _ := tengoLib.ToInt(bytesTengo)
+	assert.Equal(t, bytes, 20000)
+	downscale, _ := v.IndexGet(&tengoLib.String{Value: "downscale"})
+	assert.Equal(t, downscale, tengoLib.TrueValue)
+}
@@ -42,5 +42,9 @@ func decodeUsingTengo(url *url.URL, bucketConfig config.Bucket, obj *object.File
 	}
 	parent := parentTengo.String()
 
+	if err = object.CheckLimits(bucketConfig.Transform.Limits, &obj.Transforms); err != nil {
+		return "", err
+	}
+
 	return parent, err
 }
@@ -60,6 +60,8 @@ func (o *Transforms) IndexGet(index tengoLib.Object) (val tengoLib.Object, err e
 		val = &tengoLib.UserFunction{Name: "crop", Value: o.crop}
 	case "resizeCropAuto":
 		val = &tengoLib.UserFunction{Name: "resizeCropAuto", Value: o.resizeCropAuto}
+	case "focalPoint":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.focalPoint}
 	case "interlace":
 		val = &tengoLib.UserFunction{Name: "interlace", Value: o.interlace}
 	case "quality":
@@ -72,10 +74,56 @@ func (o *Transforms) IndexGet(index tengoLib.Object) (val tengoLib.Object, err e
 		val = &tengoLib.UserFunction{Name: "format", Value: o.format}
 	case "watermark":
 		val = &tengoLib.UserFunction{Name: "watermark", Value: o.watermark}
+	case "text":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.text}
+	case "extend", "pad":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.extend}
+	case "sharpen":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.sharpen}
+	case "modulate":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.modulate}
+	case "gamma":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.gamma}
+	case "tint":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.tint}
+	case "flip":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.flip}
+	case "flop":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.flop}
+	case "trim":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.trim}
+	case "zoom":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.zoom}
+	case "frame", "page":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.frame}
+	case "dpi":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.dpi}
+	case "info":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.info}
+	case "palette":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.palette}
+	case "mask":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.mask}
+	case "colorProfile":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.colorProfile}
 	case "grayscale":
 		val = &tengoLib.UserFunction{Name: strIdx, Value: o.grayscale}
 	case "rotate":
 		val = &tengoLib.UserFunction{Name: strIdx, Value: o.rotate}
+	case "speed":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.speed}
+	case "effort":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.effort}
+	case "lossless":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.lossless}
+	case "jpegOptions":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.jpegOptions}
+	case "pngOptions":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.pngOptions}
+	case "webpOptions":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.webpOptions}
+	case "maxBytes":
+		val = &tengoLib.UserFunction{Name: strIdx, Value: o.maxBytes}
 	}
 
 	return val, nil
@@ -181,6 +229,24 @@ func (o *Transforms) resizeCropAuto(args ...tengoLib.Object) (ret tengoLib.Objec
 	return tengo.UndefinedValue, err
 }
 
+func (o *Transforms) focalPoint(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 2 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var ok bool
+	var x, y float64
+	if x, ok = tengoLib.ToFloat64(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "x", Expected: "float64", Found: args[0].TypeName()}
+	}
+
+	if y, ok = tengoLib.ToFloat64(args[1]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "y", Expected: "float64", Found: args[1].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.FocalPoint(x, y)
+}
+
 func (o *Transforms) interlace(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
 
 	err = o.Value.Interlace()
@@ -225,6 +291,69 @@ func (o *Transforms) blur(args ...tengoLib.Object) (ret tengoLib.Object, err err
 	return tengo.UndefinedValue, o.Value.Blur(sigma, minAmpl)
 }
 
+func (o *Transforms) sharpen(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 3 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var values [3]float64
+	for i, name := range []string{"sigma", "flat", "jagged"} {
+		var ok bool
+		if values[i], ok = tengoLib.ToFloat64(args[i]); !ok {
+			return nil, tengoLib.ErrInvalidArgumentType{Name: name, Expected: "float64", Found: args[i].TypeName()}
+		}
+	}
+
+	return tengo.UndefinedValue, o.Value.Sharpen(values[0], values[1], values[2])
+}
+
+func (o *Transforms) modulate(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 4 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var values [4]float64
+	for i, name := range []string{"brightness", "contrast", "saturation", "hue"} {
+		var ok bool
+		if values[i], ok = tengoLib.ToFloat64(args[i]); !ok {
+			return nil, tengoLib.ErrInvalidArgumentType{Name: name, Expected: "float64", Found: args[i].TypeName()}
+		}
+	}
+
+	return tengo.UndefinedValue, o.Value.Modulate(values[0], values[1], values[2], values[3])
+}
+
+func (o *Transforms) gamma(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	exponent, ok := tengoLib.ToFloat64(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "exponent", Expected: "float64", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Gamma(exponent)
+}
+
+func (o *Transforms) tint(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 2 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var color, shadow string
+	var ok bool
+	if color, ok = tengoLib.ToString(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "color", Expected: "string", Found: args[0].TypeName()}
+	}
+
+	if shadow, ok = tengoLib.ToString(args[1]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "shadow", Expected: "string", Found: args[1].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Tint(color, shadow)
+}
+
 func (o *Transforms) format(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
 	if len(args) != 1 {
 		return nil, tengoLib.ErrWrongNumArguments
@@ -240,7 +369,7 @@ func (o *Transforms) format(args ...tengoLib.Object) (ret tengoLib.Object, err e
 }
 
 func (o *Transforms) watermark(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
-	if len(args) != 3 {
+	if len(args) != 3 && len(args) != 6 {
 		return nil, tengoLib.ErrWrongNumArguments
 	}
 
@@ -259,7 +388,93 @@ func (o *Transforms) watermark(args ...tengoLib.Object) (ret tengoLib.Object, er
 		return nil, tengoLib.ErrInvalidArgumentType{Name: "opacity", Expected: "float64", Found: args[2].TypeName()}
 	}
 
-	return tengo.UndefinedValue, o.Value.Watermark(image, position, float32(opacity))
+	if err = o.Value.Watermark(image, position, float32(opacity)); err != nil || len(args) == 3 {
+		return tengo.UndefinedValue, err
+	}
+
+	var scale float64
+	var margin int
+	var tile bool
+	if scale, ok = tengoLib.ToFloat64(args[3]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "scale", Expected: "float64", Found: args[3].TypeName()}
+	}
+
+	if margin, ok = tengoLib.ToInt(args[4]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "margin", Expected: "int", Found: args[4].TypeName()}
+	}
+
+	if tile, ok = tengoLib.ToBool(args[5]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "tile", Expected: "bool", Found: args[5].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.WatermarkLayout(scale, margin, tile)
+}
+
+func (o *Transforms) text(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 7 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var ok bool
+	var text, font, color, position string
+	var size, dpi int
+	var opacity float64
+	if text, ok = tengoLib.ToString(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "text", Expected: "string", Found: args[0].TypeName()}
+	}
+
+	if font, ok = tengoLib.ToString(args[1]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "font", Expected: "string", Found: args[1].TypeName()}
+	}
+
+	if size, ok = tengoLib.ToInt(args[2]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "size", Expected: "int", Found: args[2].TypeName()}
+	}
+
+	if color, ok = tengoLib.ToString(args[3]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "color", Expected: "string", Found: args[3].TypeName()}
+	}
+
+	if position, ok = tengoLib.ToString(args[4]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "position", Expected: "string", Found: args[4].TypeName()}
+	}
+
+	if opacity, ok = tengoLib.ToFloat64(args[5]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "opacity", Expected: "float64", Found: args[5].TypeName()}
+	}
+
+	if dpi, ok = tengoLib.ToInt(args[6]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "dpi", Expected: "int", Found: args[6].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Text(text, font, size, color, position, float32(opacity), dpi)
+}
+
+func (o *Transforms) extend(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 4 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var width, height int
+	var background, mode string
+	var ok bool
+	if width, ok = tengoLib.ToInt(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "width", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	if height, ok = tengoLib.ToInt(args[1]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "height", Expected: "int", Found: args[1].TypeName()}
+	}
+
+	if background, ok = tengoLib.ToString(args[2]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "background", Expected: "string", Found: args[2].TypeName()}
+	}
+
+	if mode, ok = tengoLib.ToString(args[3]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "mode", Expected: "string", Found: args[3].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Extend(width, height, background, mode)
 }
 
 func (o *Transforms) grayscale(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
@@ -268,6 +483,133 @@ func (o *Transforms) grayscale(_ ...tengoLib.Object) (ret tengoLib.Object, err e
 	return tengo.UndefinedValue, nil
 }
 
+func (o *Transforms) info(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	o.Value.Info()
+	return tengo.UndefinedValue, nil
+}
+
+func (o *Transforms) mask(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 3 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	shape, ok := tengoLib.ToString(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "shape", Expected: "string", Found: args[0].TypeName()}
+	}
+
+	radius, ok := tengoLib.ToInt(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "radius", Expected: "int", Found: args[1].TypeName()}
+	}
+
+	image, ok := tengoLib.ToString(args[2])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "image", Expected: "string", Found: args[2].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Mask(shape, radius, image)
+}
+
+func (o *Transforms) colorProfile(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 2 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	target, ok := tengoLib.ToString(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "target", Expected: "string", Found: args[0].TypeName()}
+	}
+
+	embed, ok := tengoLib.ToBool(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "embed", Expected: "bool", Found: args[1].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.ColorProfile(target, embed)
+}
+
+func (o *Transforms) palette(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	colors, ok := tengoLib.ToInt(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "colors", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Palette(colors)
+}
+
+func (o *Transforms) flip(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	o.Value.Flip()
+	return tengo.UndefinedValue, nil
+}
+
+func (o *Transforms) flop(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	o.Value.Flop()
+	return tengo.UndefinedValue, nil
+}
+
+func (o *Transforms) trim(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 2 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var threshold float64
+	var background string
+	var ok bool
+	if threshold, ok = tengoLib.ToFloat64(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "threshold", Expected: "float64", Found: args[0].TypeName()}
+	}
+
+	if background, ok = tengoLib.ToString(args[1]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "background", Expected: "string", Found: args[1].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Trim(threshold, background)
+}
+
+func (o *Transforms) zoom(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	factor, ok := tengoLib.ToInt(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "factor", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Zoom(factor)
+}
+
+func (o *Transforms) frame(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	frame, ok := tengoLib.ToInt(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "frame", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Frame(frame)
+}
+
+func (o *Transforms) dpi(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	dpi, ok := tengoLib.ToInt(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "dpi", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.DPI(dpi)
+}
+
 func (o *Transforms) rotate(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
 	if len(args) != 1 {
 		return nil, tengoLib.ErrWrongNumArguments
@@ -281,3 +623,127 @@ func (o *Transforms) rotate(args ...tengoLib.Object) (ret tengoLib.Object, err e
 
 	return tengo.UndefinedValue, o.Value.Rotate(angle)
 }
+
+func (o *Transforms) speed(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var ok bool
+	var speed int
+	if speed, ok = tengoLib.ToInt(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "speed", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Speed(speed)
+}
+
+func (o *Transforms) effort(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 1 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	var ok bool
+	var effort int
+	if effort, ok = tengoLib.ToInt(args[0]); !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "effort", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.Effort(effort)
+}
+
+func (o *Transforms) lossless(_ ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	return tengo.UndefinedValue, o.Value.Lossless()
+}
+
+func (o *Transforms) jpegOptions(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 4 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	progressive, ok := tengoLib.ToBool(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "progressive", Expected: "bool", Found: args[0].TypeName()}
+	}
+
+	subsample, ok := tengoLib.ToString(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "subsample", Expected: "string", Found: args[1].TypeName()}
+	}
+
+	trellis, ok := tengoLib.ToBool(args[2])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "trellis", Expected: "bool", Found: args[2].TypeName()}
+	}
+
+	quantTable, ok := tengoLib.ToInt(args[3])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "quantTable", Expected: "int", Found: args[3].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.JpegOptions(progressive, subsample, trellis, quantTable)
+}
+
+func (o *Transforms) pngOptions(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 3 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	palette, ok := tengoLib.ToBool(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "palette", Expected: "bool", Found: args[0].TypeName()}
+	}
+
+	compression, ok := tengoLib.ToInt(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "compression", Expected: "int", Found: args[1].TypeName()}
+	}
+
+	colors, ok := tengoLib.ToInt(args[2])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "colors", Expected: "int", Found: args[2].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.PngOptions(palette, compression, colors)
+}
+
+func (o *Transforms) webpOptions(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 3 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	nearLossless, ok := tengoLib.ToBool(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "nearLossless", Expected: "bool", Found: args[0].TypeName()}
+	}
+
+	effort, ok := tengoLib.ToInt(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "effort", Expected: "int", Found: args[1].TypeName()}
+	}
+
+	smartSubsample, ok := tengoLib.ToBool(args[2])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "smartSubsample", Expected: "bool", Found: args[2].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.WebpOptions(nearLossless, effort, smartSubsample)
+}
+
+func (o *Transforms) maxBytes(args ...tengoLib.Object) (ret tengoLib.Object, err error) {
+	if len(args) != 2 {
+		return nil, tengoLib.ErrWrongNumArguments
+	}
+
+	maxBytes, ok := tengoLib.ToInt(args[0])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "maxBytes", Expected: "int", Found: args[0].TypeName()}
+	}
+
+	downscale, ok := tengoLib.ToBool(args[1])
+	if !ok {
+		return nil, tengoLib.ErrInvalidArgumentType{Name: "downscale", Expected: "bool", Found: args[1].TypeName()}
+	}
+
+	return tengo.UndefinedValue, o.Value.MaxBytes(maxBytes, downscale)
+}
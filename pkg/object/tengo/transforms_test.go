@@ -32,14 +32,39 @@ func TestTransformsIndexGet(t *testing.T) {
 		"extract",
 		"crop",
 		"resizeCropAuto",
+		"focalPoint",
 		"interlace",
 		"quality",
 		"stripMetadata",
 		"blur",
 		"format",
 		"watermark",
+		"text",
+		"extend",
+		"sharpen",
+		"modulate",
+		"gamma",
+		"tint",
+		"flip",
+		"flop",
+		"trim",
+		"zoom",
+		"frame",
+		"page",
+		"dpi",
+		"info",
+		"palette",
+		"mask",
+		"colorProfile",
 		"grayscale",
 		"rotate",
+		"speed",
+		"effort",
+		"lossless",
+		"jpegOptions",
+		"pngOptions",
+		"webpOptions",
+		"maxBytes",
 	}
 
 	t.Run("methods", func(t *testing.T) {
@@ -181,6 +206,234 @@ func TestTransformsCall(t *testing.T) {
 			ResultHash: noChangesHash,
 			Error:      tengoLib.ErrInvalidArgumentType{Name: "height", Expected: "int", Found: "string"},
 		},
+		TestResult{
+			Method: "focalPoint",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 0.5},
+				&tengoLib.Float{Value: 0.25},
+			},
+			Error:      nil,
+			ResultHash: "f07b306c9a0501f2",
+		},
+		TestResult{
+			Method: "focalPoint",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 0.5},
+			},
+			ResultHash: noChangesHash,
+			Error:      tengoLib.ErrWrongNumArguments,
+		},
+		TestResult{
+			Method: "text",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "caption"},
+				&tengoLib.String{Value: ""},
+				&tengoLib.Int{Value: 12},
+				&tengoLib.String{Value: "white"},
+				&tengoLib.String{Value: "bottom-left"},
+				&tengoLib.Float{Value: 0.5},
+				&tengoLib.Int{Value: 0},
+			},
+			Error:      nil,
+			ResultHash: "ef7d293e95b82e00",
+		},
+		TestResult{
+			Method: "text",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "caption"},
+			},
+			ResultHash: noChangesHash,
+			Error:      tengoLib.ErrWrongNumArguments,
+		},
+		TestResult{
+			Method: "extend",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 300},
+				&tengoLib.Int{Value: 200},
+				&tengoLib.String{Value: "transparent"},
+				&tengoLib.String{Value: "color"},
+			},
+			Error:      nil,
+			ResultHash: "1b0a75e9ddcd5102",
+		},
+		TestResult{
+			Method: "extend",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 300},
+			},
+			ResultHash: noChangesHash,
+			Error:      tengoLib.ErrWrongNumArguments,
+		},
+		TestResult{
+			Method: "sharpen",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 1.5},
+				&tengoLib.Float{Value: 0},
+				&tengoLib.Float{Value: 2},
+			},
+			Error:      nil,
+			ResultHash: "ce59a2a17a6da56e",
+		},
+		TestResult{
+			Method: "modulate",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 1.2},
+				&tengoLib.Float{Value: 1},
+				&tengoLib.Float{Value: 0.5},
+				&tengoLib.Float{Value: 90},
+			},
+			Error:      nil,
+			ResultHash: "9a1aeb555959b006",
+		},
+		TestResult{
+			Method: "gamma",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 2.2},
+			},
+			Error:      nil,
+			ResultHash: "af5eba15df02c5a1",
+		},
+		TestResult{
+			Method: "tint",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "ff0000"},
+				&tengoLib.String{Value: ""},
+			},
+			Error:      nil,
+			ResultHash: "3a7e4c26a7566be9",
+		},
+		TestResult{
+			Method: "tint",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "ff0000"},
+			},
+			ResultHash: noChangesHash,
+			Error:      tengoLib.ErrWrongNumArguments,
+		},
+		TestResult{
+			Method:     "flip",
+			Args:       []tengoLib.Object{},
+			Error:      nil,
+			ResultHash: "3ff7d4b7348b40cd",
+		},
+		TestResult{
+			Method:     "flop",
+			Args:       []tengoLib.Object{},
+			Error:      nil,
+			ResultHash: "b663cc54f34dbb7d",
+		},
+		TestResult{
+			Method: "trim",
+			Args: []tengoLib.Object{
+				&tengoLib.Float{Value: 20},
+				&tengoLib.String{Value: "white"},
+			},
+			Error:      nil,
+			ResultHash: "ac9008bf6d6e8107",
+		},
+		TestResult{
+			Method: "zoom",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 2},
+			},
+			Error:      nil,
+			ResultHash: "75f4863e0182cd9c",
+		},
+		TestResult{
+			Method:     "zoom",
+			Args:       []tengoLib.Object{},
+			ResultHash: noChangesHash,
+			Error:      tengoLib.ErrWrongNumArguments,
+		},
+		TestResult{
+			Method: "frame",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 1},
+			},
+			Error:      nil,
+			ResultHash: "f1bace0a597af6a1",
+		},
+		TestResult{
+			Method: "dpi",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 150},
+			},
+			Error:      nil,
+			ResultHash: "a6632500b1975822",
+		},
+		TestResult{
+			Method:     "info",
+			Args:       []tengoLib.Object{},
+			Error:      nil,
+			ResultHash: "40b613edafa6c68b",
+		},
+		TestResult{
+			Method: "palette",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 6},
+			},
+			Error:      nil,
+			ResultHash: "965c609629776f09",
+		},
+		TestResult{
+			Method: "mask",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "rounded"},
+				&tengoLib.Int{Value: 10},
+				&tengoLib.String{Value: ""},
+			},
+			Error:      nil,
+			ResultHash: "e56867ba685d0ab9",
+		},
+		TestResult{
+			Method: "colorProfile",
+			Args: []tengoLib.Object{
+				&tengoLib.String{Value: "p3"},
+				tengoLib.FalseValue,
+			},
+			Error:      nil,
+			ResultHash: "a2acf3fa15d0878c",
+		},
+		TestResult{
+			Method: "jpegOptions",
+			Args: []tengoLib.Object{
+				tengoLib.TrueValue,
+				&tengoLib.String{Value: "off"},
+				tengoLib.FalseValue,
+				&tengoLib.Int{Value: 3},
+			},
+			Error:      nil,
+			ResultHash: "a5e357e5fba19ce9",
+		},
+		TestResult{
+			Method: "pngOptions",
+			Args: []tengoLib.Object{
+				tengoLib.TrueValue,
+				&tengoLib.Int{Value: 9},
+				&tengoLib.Int{Value: 16},
+			},
+			Error:      nil,
+			ResultHash: "61a72665e0c451c5",
+		},
+		TestResult{
+			Method: "webpOptions",
+			Args: []tengoLib.Object{
+				tengoLib.TrueValue,
+				&tengoLib.Int{Value: 5},
+				tengoLib.TrueValue,
+			},
+			Error:      nil,
+			ResultHash: "54ad5b728c459c8",
+		},
+		TestResult{
+			Method: "maxBytes",
+			Args: []tengoLib.Object{
+				&tengoLib.Int{Value: 20000},
+				tengoLib.TrueValue,
+			},
+			Error:      nil,
+			ResultHash: "83cc4f8ab5f44edd",
+		},
 		TestResult{
 			Method:     "interlace",
 			Args:       []tengoLib.Object{},
@@ -0,0 +1,146 @@
+package thumbor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// filter holds single thumbor filter with its arguments
+type filter struct {
+	name string
+	args []string
+}
+
+// filters holds parsed filters part of thumbor URL
+type filters struct {
+	list    []filter
+	upscale bool
+}
+
+// parseFilters parse filters in form name(arg1,arg2):name2()
+func parseFilters(value string) (filters, error) {
+	result := filters{}
+	if value == "" {
+		return result, nil
+	}
+
+	for _, f := range splitFilters(value) {
+		open := strings.Index(f, "(")
+		if open == -1 || !strings.HasSuffix(f, ")") {
+			return result, fmt.Errorf("invalid filter %s", f)
+		}
+
+		parsed := filter{name: f[:open]}
+		if args := f[open+1 : len(f)-1]; args != "" {
+			parsed.args = strings.Split(args, ",")
+		}
+
+		switch parsed.name {
+		case "upscale":
+			result.upscale = true
+		case "no_upscale":
+		default:
+			result.list = append(result.list, parsed)
+		}
+	}
+
+	return result, nil
+}
+
+// splitFilters split filters by ":" which are outside of parentheses
+func splitFilters(value string) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i, c := range value {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ':':
+			if depth == 0 {
+				result = append(result, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(result, value[start:])
+}
+
+// apply add filters to transforms
+func (f filters) apply(result *transforms.Transforms) error {
+	for _, filter := range f.list {
+		if err := filter.apply(result); err != nil {
+			return fmt.Errorf("filter %s: %w", filter.name, err)
+		}
+	}
+
+	return nil
+}
+
+func (f filter) apply(result *transforms.Transforms) error {
+	switch f.name {
+	case "quality":
+		q, err := f.intArg(0)
+		if err != nil {
+			return err
+		}
+		return result.Quality(q)
+	case "grayscale":
+		result.Grayscale()
+		return nil
+	case "blur":
+		radius, err := f.floatArg(0)
+		if err != nil {
+			return err
+		}
+		sigma := radius
+		if len(f.args) > 1 {
+			if sigma, err = f.floatArg(1); err != nil {
+				return err
+			}
+		}
+		return result.Blur(sigma, 0)
+	case "rotate":
+		angle, err := f.intArg(0)
+		if err != nil {
+			return err
+		}
+		return result.Rotate((angle%360 + 360) % 360)
+	case "format":
+		if len(f.args) != 1 {
+			return fmt.Errorf("format is required")
+		}
+		return result.Format(f.args[0])
+	case "strip_exif", "strip_icc":
+		return result.StripMetadata()
+	case "background_color":
+		if len(f.args) != 1 {
+			return fmt.Errorf("color is required")
+		}
+		return result.Background(f.args[0])
+	default:
+		return fmt.Errorf("not supported")
+	}
+}
+
+func (f filter) intArg(i int) (int, error) {
+	if i >= len(f.args) {
+		return 0, fmt.Errorf("missing argument")
+	}
+
+	return strconv.Atoi(strings.TrimSpace(f.args[i]))
+}
+
+func (f filter) floatArg(i int) (float64, error) {
+	if i >= len(f.args) {
+		return 0, fmt.Errorf("missing argument")
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(f.args[i]), 64)
+}
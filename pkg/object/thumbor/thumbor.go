@@ -0,0 +1,237 @@
+package thumbor
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// Kind name of thumbor transform kind
+const Kind = "thumbor"
+
+const unsafeSignature = "unsafe"
+
+// thumborPath matches thumbor URL without signature part
+var thumborPath = regexp.MustCompile(`^(?:(?P<trim>trim(?::[^/]+)?)/)?` +
+	`(?:(?P<crop>\d+x\d+:\d+x\d+)/)?` +
+	`(?:(?P<fitin>(?:full-|adaptive-)?fit-in)/)?` +
+	`(?:(?P<size>-?\d*x-?\d*)/)?` +
+	`(?:(?P<halign>left|right|center)/)?` +
+	`(?:(?P<valign>top|bottom|middle)/)?` +
+	`(?:(?P<smart>smart)/)?` +
+	`(?:filters:(?P<filters>.+?\))/)?` +
+	`(?P<image>.+)$`)
+
+var cropCoordinates = regexp.MustCompile(`^(\d+)x(\d+):(\d+)x(\d+)$`)
+
+func init() {
+	object.RegisterParser(Kind, decode)
+}
+
+// decode parse thumbor URL in form /<signature>/[trim/][AxB:CxD/][fit-in/][-]Ex[-]F/[halign/][valign/][smart/][filters:.../]image
+func decode(u *url.URL, bucketConfig config.Bucket, obj *object.FileObject) (string, error) {
+	trans := bucketConfig.Transform
+	escapedPath := strings.TrimPrefix(u.EscapedPath(), "/"+obj.Bucket+"/")
+	elements := strings.SplitN(escapedPath, "/", 2)
+	if len(elements) != 2 {
+		return "", errors.New("invalid thumbor path")
+	}
+
+	if err := verifySignature(trans.Thumbor, elements[0], elements[1]); err != nil {
+		return "", err
+	}
+
+	thumborURL, err := url.PathUnescape(elements[1])
+	if err != nil {
+		return "", err
+	}
+
+	matches := thumborPath.FindStringSubmatch(thumborURL)
+	if matches == nil {
+		return "", errors.New("invalid thumbor path")
+	}
+
+	parts := make(map[string]string)
+	for i, name := range thumborPath.SubexpNames() {
+		if i != 0 && name != "" {
+			parts[name] = matches[i]
+		}
+	}
+
+	steps, err := partsToTransforms(parts)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range steps {
+		if err = object.CheckLimits(trans.Limits, &steps[i]); err != nil {
+			return "", err
+		}
+	}
+
+	parent, err := object.ParentFromURL(parts["image"], trans, obj.Bucket)
+	if err != nil {
+		return "", err
+	}
+
+	// manual crop is done in separate step, its coordinates are in space of original image
+	obj.Transforms = steps[len(steps)-1]
+	if len(steps) > 1 {
+		obj.Parent = object.NewStepObject(obj.Bucket, "/"+strings.TrimPrefix(parts["image"], "/")+"-crop-"+parts["crop"], steps[0])
+	}
+
+	return parent, nil
+}
+
+// verifySignature checks HMAC-SHA1 signature of URL, "unsafe" URLs are accepted only when they are explicitly allowed
+func verifySignature(cfg *config.ThumborCfg, signature, signedPath string) error {
+	if cfg == nil {
+		return fmt.Errorf("%w: thumbor is not configured", object.ErrInvalidSignature)
+	}
+
+	if signature == unsafeSignature {
+		if cfg.AllowUnsafe {
+			return nil
+		}
+
+		return fmt.Errorf("%w: unsafe thumbor URLs are not allowed", object.ErrInvalidSignature)
+	}
+
+	if cfg.Key == "" {
+		return fmt.Errorf("%w: thumbor key is not configured", object.ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha1.New, []byte(cfg.Key))
+	mac.Write([]byte(signedPath))
+	expected := base64.URLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf("%w: thumbor signature mismatch", object.ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+// partsToTransforms returns steps of transformation, manual crop is a first step which isn't merged with next one
+// nolint: gocyclo
+func partsToTransforms(parts map[string]string) ([]transforms.Transforms, error) {
+	if parts["trim"] != "" {
+		return nil, errors.New("trim is not supported")
+	}
+
+	if parts["fitin"] != "" && parts["fitin"] != "fit-in" {
+		return nil, fmt.Errorf("%s is not supported", parts["fitin"])
+	}
+
+	var steps []transforms.Transforms
+	if crop := cropCoordinates.FindStringSubmatch(parts["crop"]); crop != nil {
+		left, _ := strconv.Atoi(crop[1])
+		top, _ := strconv.Atoi(crop[2])
+		right, _ := strconv.Atoi(crop[3])
+		bottom, _ := strconv.Atoi(crop[4])
+		if right <= left || bottom <= top {
+			return nil, errors.New("invalid crop coordinates")
+		}
+
+		area := transforms.New()
+		if err := area.Extract(top, left, right-left, bottom-top); err != nil {
+			return nil, err
+		}
+		area.NoMerge = true
+		steps = append(steps, area)
+	}
+
+	result := transforms.New()
+	f, err := parseFilters(parts["filters"])
+	if err != nil {
+		return nil, err
+	}
+
+	if parts["size"] != "" {
+		if err = applySize(&result, parts, f.upscale); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = f.apply(&result); err != nil {
+		return nil, err
+	}
+
+	// URL with crop only doesn't need second step
+	if len(steps) == 0 || result.NotEmpty {
+		steps = append(steps, result)
+	}
+
+	return steps, nil
+}
+
+// applySize resize image, negative dimensions mean that image should be flipped
+func applySize(result *transforms.Transforms, parts map[string]string, upscale bool) error {
+	size := strings.SplitN(parts["size"], "x", 2)
+	width, flop, err := parseDimension(size[0])
+	if err != nil {
+		return err
+	}
+
+	height, flip, err := parseDimension(size[1])
+	if err != nil {
+		return err
+	}
+
+	if flop {
+		result.Flop()
+	}
+
+	if flip {
+		result.Flip()
+	}
+
+	switch {
+	case width == 0 && height == 0:
+		return nil
+	case parts["fitin"] != "":
+		return result.Resize(width, height, upscale, true, false)
+	case width == 0 || height == 0:
+		return result.Resize(width, height, upscale, false, false)
+	case parts["smart"] != "":
+		return result.Crop(width, height, "smart", upscale, false)
+	default:
+		return result.Crop(width, height, alignGravity(parts["halign"], parts["valign"]), upscale, false)
+	}
+}
+
+func parseDimension(value string) (int, bool, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	if value == "" {
+		return 0, negative, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	return v, negative, err
+}
+
+// alignGravity maps thumbor alignment to gravity, vertical alignment is preferred for corners
+func alignGravity(halign, valign string) string {
+	switch {
+	case valign == "top":
+		return "north"
+	case valign == "bottom":
+		return "south"
+	case halign == "left":
+		return "west"
+	case halign == "right":
+		return "east"
+	default:
+		return "center"
+	}
+}
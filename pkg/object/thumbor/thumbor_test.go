@@ -0,0 +1,227 @@
+package thumbor
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/transforms"
+	"github.com/h2non/bimg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const thumborConfig = `
+buckets:
+    thumbor:
+        transform:
+            kind: "thumbor"
+            parentBucket: "media"
+            thumbor:
+                key: "MY_SECURE_KEY"
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    unsafe:
+        transform:
+            kind: "thumbor"
+            parentBucket: "media"
+            thumbor:
+                allowUnsafe: true
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    noconfig:
+        transform:
+            kind: "thumbor"
+            parentBucket: "media"
+        storages:
+            basic:
+                kind: "noop"
+            transform:
+                kind: "noop"
+    media:
+        storages:
+            basic:
+                kind: "noop"
+`
+
+func loadConfig(t *testing.T) *config.Config {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(thumborConfig)
+	require.Nil(t, err)
+	return &mortConfig
+}
+
+func sign(path string) string {
+	mac := hmac.New(sha1.New, []byte("MY_SECURE_KEY"))
+	mac.Write([]byte(path))
+	return base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestDecodeSigned(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	path := "fit-in/300x200/filters:quality(80):format(webp)/dir/img.jpg"
+	u, _ := url.Parse("/thumbor/" + sign(path) + "/" + path)
+	obj, err := object.NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 300, width)
+	assert.Equal(t, 200, height)
+	assert.Equal(t, "webp", obj.Transforms.FormatStr)
+	assert.Equal(t, 80, obj.Transforms.Summary().Quality)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "media", obj.Parent.Bucket)
+	assert.Equal(t, "/dir/img.jpg", obj.Parent.Key)
+}
+
+func TestDecodeInvalidSignature(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	path := "300x200/dir/img.jpg"
+	u, _ := url.Parse("/thumbor/" + sign(path) + "/301x200/dir/img.jpg")
+	_, err := object.NewFileObject(u, mortConfig)
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+
+	u, _ = url.Parse("/unsafe/" + sign(path) + "/" + path)
+	_, err = object.NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+
+	u, _ = url.Parse("/thumbor/unsafe/" + path)
+	_, err = object.NewFileObject(u, mortConfig)
+	assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+
+	for _, signature := range []string{"unsafe", sign(path)} {
+		u, _ = url.Parse("/noconfig/" + signature + "/" + path)
+		_, err = object.NewFileObject(u, mortConfig)
+		assert.True(t, errors.Is(err, object.ErrInvalidSignature))
+	}
+}
+
+func TestThumborConfig(t *testing.T) {
+	tests := []struct {
+		thumbor string
+		valid   bool
+	}{
+		{`key: "MY_SECURE_KEY"`, true},
+		{`allowUnsafe: true`, true},
+		{`allowUnsafe: false`, false},
+		{"key: \"MY_SECURE_KEY\"\n                allowUnsafe: true", false},
+	}
+
+	for _, tt := range tests {
+		mortConfig := config.Config{}
+		err := mortConfig.LoadFromString(`
+buckets:
+    thumbor:
+        transform:
+            kind: "thumbor"
+            thumbor:
+                ` + tt.thumbor + `
+        storages:
+            basic:
+                kind: "noop"
+`)
+		assert.Equal(t, tt.valid, err == nil, tt.thumbor)
+	}
+}
+
+func TestDecodeUnsafe(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	u, _ := url.Parse("/unsafe/unsafe/10x20:110x220/-300x-200/left/top/filters:blur(3):background_color(fff)/dir/img.jpg")
+	obj, err := object.NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 300, width)
+	assert.Equal(t, 200, height)
+	assert.ElementsMatch(t, []string{"crop", "flip", "flop", "blur"}, obj.Transforms.Summary().Operations)
+
+	imageInfo := transforms.NewImageInfo(bimg.ImageMetadata{Size: bimg.ImageSize{Width: 1000, Height: 800}}, "jpeg")
+	opts, err := obj.Transforms.BimgOptions(imageInfo)
+	require.Nil(t, err)
+	require.Len(t, opts, 1)
+	assert.True(t, opts[0].Crop)
+	assert.Equal(t, 300, opts[0].Width)
+	assert.Equal(t, 200, opts[0].Height)
+	assert.Equal(t, 0, opts[0].AreaWidth)
+
+	// manual crop is done on original image before resize
+	require.True(t, obj.HasParent())
+	step := obj.Parent
+	assert.True(t, step.Transforms.NoMerge)
+	assert.Equal(t, []string{"extract"}, step.Transforms.Summary().Operations)
+	opts, err = step.Transforms.BimgOptions(imageInfo)
+	require.Nil(t, err)
+	require.Len(t, opts, 1)
+	assert.False(t, opts[0].Crop)
+	assert.Equal(t, 0, opts[0].Width)
+	assert.Equal(t, 10, opts[0].Left)
+	assert.Equal(t, 20, opts[0].Top)
+	assert.Equal(t, 100, opts[0].AreaWidth)
+	assert.Equal(t, 200, opts[0].AreaHeight)
+
+	require.True(t, step.HasParent())
+	assert.Equal(t, "media", step.Parent.Bucket)
+	assert.Equal(t, "/dir/img.jpg", step.Parent.Key)
+	assert.Len(t, transforms.Merge([]transforms.Transforms{obj.Transforms, step.Transforms}), 2)
+}
+
+func TestDecodeCropOnly(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	u, _ := url.Parse("/unsafe/unsafe/10x20:110x220/dir/img.jpg")
+	obj, err := object.NewFileObject(u, mortConfig)
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"extract"}, obj.Transforms.Summary().Operations)
+	require.True(t, obj.HasParent())
+	assert.Equal(t, "/dir/img.jpg", obj.Parent.Key)
+}
+
+func TestAlignGravity(t *testing.T) {
+	assert.Equal(t, "north", alignGravity("left", "top"))
+	assert.Equal(t, "south", alignGravity("", "bottom"))
+	assert.Equal(t, "west", alignGravity("left", "middle"))
+	assert.Equal(t, "east", alignGravity("right", ""))
+	assert.Equal(t, "center", alignGravity("center", "middle"))
+}
+
+func TestSplitFilters(t *testing.T) {
+	assert.Equal(t, []string{"quality(80)", "rotate(90)", "format(png)"}, splitFilters("quality(80):rotate(90):format(png)"))
+	assert.Equal(t, []string{"background_color(rgb:1)"}, splitFilters("background_color(rgb:1)"))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	mortConfig := loadConfig(t)
+
+	tests := []string{
+		"/unsafe/unsafe/trim/300x200/img.jpg",
+		"/unsafe/unsafe/full-fit-in/300x200/img.jpg",
+		"/unsafe/unsafe/100x100:50x50/img.jpg",
+		"/unsafe/unsafe/300x200/filters:watermark(a.png,0,0,0)/img.jpg",
+		"/unsafe/unsafe/300x200/filters:quality(abc)/img.jpg",
+		"/unsafe/unsafe",
+	}
+
+	for _, path := range tests {
+		t.Run(path, func(t *testing.T) {
+			u, _ := url.Parse(path)
+			_, err := object.NewFileObject(u, mortConfig)
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -55,6 +56,13 @@ func Parse(url *url.URL, mortConfig *config.Config, obj *FileObject) error {
 	if bucketConfig.Transform == nil {
 		return nil
 	}
+	obj.Limits = bucketConfig.Transform.Limits
+	// Signature is checked before any transform is built from URL.
+	if signing := bucketConfig.Transform.Signing; signing != nil && requiresSignature(url, bucketConfig.Transform, signing) {
+		if err := verifySignature(url, signing); err != nil {
+			return err
+		}
+	}
 	// Get transform parser and execute it.
 	fn, ok := parsers[bucketConfig.Transform.Kind]
 	if !ok {
@@ -75,11 +83,25 @@ func Parse(url *url.URL, mortConfig *config.Config, obj *FileObject) error {
 		return fmt.Errorf("failed to get transformed object for %s: %w", parent, err)
 	}
 	parentObj.Storage = bucketConfig.Storages.Get(bucketConfig.Transform.ParentStorage)
-	obj.Parent = parentObj
+	// Parser can create chain of intermediate objects (multi step transforms), parent is the last one.
+	lastObj := obj
+	for lastObj.HasParent() {
+		lastObj = lastObj.Parent
+	}
+	lastObj.Parent = parentObj
 	obj.CheckParent = bucketConfig.Transform.CheckParent
 	// In case of no transformation available object will be fetched from parent
 	// without creating the duplicate in the transform storage.
 	obj.Storage = bucketConfig.Storages.Noop()
+	if err = applyEngineLimits(bucketConfig.Transform.Limits, obj, parentObj); err != nil {
+		return err
+	}
+	// metadata policy of bucket is used when transform doesn't set own one
+	if metadata := bucketConfig.Metadata; metadata != nil && obj.Transforms.NotEmpty && !obj.Transforms.HasMetadataPolicy() {
+		if err = obj.Transforms.Metadata(metadata.Policy, metadata.Tags); err != nil {
+			return err
+		}
+	}
 	if obj.Transforms.NotEmpty {
 		obj.Storage = bucketConfig.Storages.Transform()
 		if obj.AllowChangeKey {
@@ -148,6 +170,49 @@ func hashKeyParent(obj *FileObject) string {
 	return bufKey.String()
 }
 
+// ParentFromURL maps source URL used by external URL formats (imgproxy, thumbor) to parent object path
+// when parentBucket of transform is empty host of absolute URL (e.g. s3://bucket/key) or first path element is used as bucket name,
+// such bucket has to be listed in parentBuckets of transform, without the list only bucket of request can be used
+func ParentFromURL(source string, trans *config.Transform, bucket string) (string, error) {
+	if source == "" {
+		return "", errors.New("missing source URL")
+	}
+
+	s, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	for _, segment := range strings.Split(s.Path, "/") {
+		if segment == ".." {
+			return "", errors.New("source URL can't contain parent directory references")
+		}
+	}
+
+	if trans.ParentBucket != "" {
+		return "/" + path.Join(trans.ParentBucket, s.Path), nil
+	}
+
+	key := strings.TrimPrefix(s.Path, "/")
+	parentBucket := s.Host
+	if parentBucket == "" {
+		parentBucket, key, _ = strings.Cut(key, "/")
+	}
+
+	allowed := trans.ParentBuckets
+	if len(allowed) == 0 {
+		allowed = []string{bucket}
+	}
+
+	for _, b := range allowed {
+		if b == parentBucket {
+			return "/" + path.Join(parentBucket, key), nil
+		}
+	}
+
+	return "", fmt.Errorf("bucket %s of source URL is not allowed", parentBucket)
+}
+
 // RegisterParser add new kind of function to map of decoders and for config validator
 func RegisterParser(kind string, fn ParseFnc) {
 	parsers[kind] = fn
@@ -0,0 +1,41 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParentFromURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		trans  config.Transform
+		parent string
+		err    bool
+	}{
+		{"parent bucket", "http://example.com/img/a.jpg", config.Transform{ParentBucket: "media"}, "/media/img/a.jpg", false},
+		{"host as bucket", "s3://media/img/a.jpg", config.Transform{ParentBuckets: []string{"media"}}, "/media/img/a.jpg", false},
+		{"path as bucket", "media/img/a.jpg", config.Transform{ParentBuckets: []string{"media"}}, "/media/img/a.jpg", false},
+		{"request bucket", "/images/img/a.jpg", config.Transform{}, "/images/img/a.jpg", false},
+		{"bucket not allowed", "s3://private/a.jpg", config.Transform{ParentBuckets: []string{"media"}}, "", true},
+		{"other bucket without list", "s3://media/a.jpg", config.Transform{}, "", true},
+		{"parent reference", "http://example.com/../private/a.jpg", config.Transform{ParentBucket: "media"}, "", true},
+		{"parent reference in key", "media/img/../../private/a.jpg", config.Transform{ParentBuckets: []string{"media"}}, "", true},
+		{"empty source", "", config.Transform{ParentBucket: "media"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent, err := ParentFromURL(tt.source, &tt.trans, "images")
+			if tt.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.parent, parent)
+		})
+	}
+}
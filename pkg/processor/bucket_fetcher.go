@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/helpers"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/storage"
+)
+
+// bucketScheme scheme of URIs which point to objects in mort buckets e.x. mort://watermarks/logo.png
+const bucketScheme = "mort"
+
+func init() {
+	helpers.RegisterFetcher(bucketScheme, fetchBucketObject)
+}
+
+// fetchBucketObject returns content of object from mort bucket storage
+func fetchBucketObject(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := object.NewFileObjectFromPath("/"+u.Host+u.Path, config.GetInstance())
+	if err != nil {
+		return nil, err
+	}
+
+	res := storage.Get(obj)
+	defer res.Close()
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("unable to fetch %s, status code %d", uri, res.StatusCode)
+	}
+
+	return res.Body()
+}
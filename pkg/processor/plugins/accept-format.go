@@ -0,0 +1,175 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/response"
+	"github.com/aldor007/mort/pkg/transforms"
+)
+
+// originalFormat is a name used in preference list to keep format of source image
+const originalFormat = "original"
+
+func init() {
+	RegisterPlugin("format-negotiation", &FormatNegotiationPlugin{})
+	config.RegisterPluginValidator("format-negotiation", func(cfg interface{}) error {
+		return (&FormatNegotiationPlugin{}).configure(cfg)
+	})
+}
+
+// FormatNegotiationPlugin plugin that choose best output format supported by client based on Accept header
+type FormatNegotiationPlugin struct {
+	formats  []string // ordered list of preferred formats
+	autoOnly bool     // negotiate only for transforms which request it (e.g. cloudinary f_auto)
+}
+
+func (f *FormatNegotiationPlugin) enabled(obj *object.FileObject) bool {
+	return obj != nil && obj.HasTransform() && (!f.autoOnly || obj.Transforms.HasAutoFormat())
+}
+
+func (f *FormatNegotiationPlugin) configure(config interface{}) error {
+	f.formats = []string{"avif", "webp"}
+	f.autoOnly = false
+	if config == nil {
+		return nil
+	}
+
+	cfg, ok := config.(map[interface{}]interface{})
+	if !ok {
+		return errors.New("configuration should be a map")
+	}
+
+	if autoOnly, ok := cfg["autoOnly"]; ok {
+		if f.autoOnly, ok = autoOnly.(bool); !ok {
+			return errors.New("autoOnly should be a boolean")
+		}
+	}
+
+	formats, ok := cfg["formats"]
+	if !ok {
+		return nil
+	}
+
+	formatsList, ok := formats.([]interface{})
+	if !ok {
+		return errors.New("formats should be a list")
+	}
+
+	f.formats = f.formats[:0]
+	for _, v := range formatsList {
+		format, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("invalid format %v", v)
+		}
+
+		if format != originalFormat {
+			t := transforms.New()
+			if err := t.Format(format); err != nil {
+				return err
+			}
+		}
+		f.formats = append(f.formats, format)
+	}
+
+	return nil
+}
+
+// preProcess change output format of object to best one accepted by client
+func (f *FormatNegotiationPlugin) preProcess(obj *object.FileObject, req *http.Request) {
+	// format given explicitly in request or preset is not changed
+	if !f.enabled(obj) || obj.Transforms.FormatStr != "" {
+		return
+	}
+
+	format := f.negotiate(req.Header.Get("Accept"))
+	if format == "" {
+		return
+	}
+
+	if err := obj.Transforms.Format(format); err == nil {
+		obj.AppendToKey(format)
+	}
+}
+
+// postProcess update vary header
+func (f *FormatNegotiationPlugin) postProcess(obj *object.FileObject, req *http.Request, res *response.Response) {
+	if res.IsImage() && f.enabled(obj) {
+		addVary(res, "Accept")
+	}
+}
+
+// negotiate returns format from preference list with highest quality value in Accept header
+// empty string means that original format should be used
+func (f *FormatNegotiationPlugin) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	accepted := parseAccept(accept)
+	best := ""
+	bestQ := 0.
+	for _, format := range f.formats {
+		// formats listed after original are never used
+		if format == originalFormat {
+			break
+		}
+
+		q, ok := accepted["image/"+format]
+		if ok && q > bestQ {
+			best = format
+			bestQ = q
+		}
+	}
+
+	return best
+}
+
+// parseAccept returns map of explicitly listed media types with theirs q-values
+// wildcards are ignored, as browsers send image/* also when they are not able to decode modern formats
+func parseAccept(accept string) map[string]float64 {
+	result := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" || strings.HasSuffix(mediaType, "/*") {
+			continue
+		}
+
+		q := 1.
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || v < 0 || v > 1 {
+					v = 0
+				}
+				q = v
+			}
+		}
+
+		if current, ok := result[mediaType]; !ok || q > current {
+			result[mediaType] = q
+		}
+	}
+
+	return result
+}
+
+// addVary add value to Vary header if it's not present yet
+func addVary(res *response.Response, value string) {
+	for _, vary := range res.Headers.Values("Vary") {
+		for _, v := range strings.Split(vary, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+
+	res.Headers.Add("Vary", value)
+}
@@ -0,0 +1,177 @@
+package plugins
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func newFormatNegotiationPlugin(t *testing.T, configStr string) *FormatNegotiationPlugin {
+	var cfg interface{}
+	err := yaml.Unmarshal([]byte(configStr), &cfg)
+	assert.Nil(t, err)
+
+	f := &FormatNegotiationPlugin{}
+	err = f.configure(cfg)
+	assert.Nil(t, err)
+	return f
+}
+
+func runFormatNegotiation(t *testing.T, f *FormatNegotiationPlugin, accept string) (*object.FileObject, *response.Response) {
+	req, _ := http.NewRequest("GET", "http://mort/local/small.jpg-m", nil)
+	if accept != "" {
+		req.Header.Add("Accept", accept)
+	}
+
+	mortConfig := config.Config{}
+	err := mortConfig.Load("../benchmark/small.yml")
+	assert.Nil(t, err)
+
+	obj, err := object.NewFileObject(req.URL, &mortConfig)
+	assert.Nil(t, err)
+
+	res := response.NewNoContent(200)
+	res.Headers.Set("content-type", "image/jpg")
+
+	obj.Ctx = req.Context()
+	f.preProcess(obj, req)
+	f.postProcess(obj, req, res)
+	return obj, res
+}
+
+func TestFormatNegotiationPreference(t *testing.T) {
+	f := newFormatNegotiationPlugin(t, `
+formats:
+  - avif
+  - webp
+  - original
+`)
+
+	obj, res := runFormatNegotiation(t, f, "image/avif,image/webp,image/apng,image/*,*/*;q=0.8")
+	assert.Equal(t, "avif", obj.Transforms.FormatStr)
+	assert.True(t, strings.HasSuffix(obj.Key, "avif"))
+	assert.Equal(t, "Accept", res.Headers.Get("Vary"))
+
+	obj, _ = runFormatNegotiation(t, f, "image/webp,image/*,*/*;q=0.8")
+	assert.Equal(t, "webp", obj.Transforms.FormatStr)
+	assert.True(t, strings.HasSuffix(obj.Key, "webp"))
+}
+
+func TestFormatNegotiationQValues(t *testing.T) {
+	f := newFormatNegotiationPlugin(t, `
+formats: [avif, webp]
+`)
+
+	obj, _ := runFormatNegotiation(t, f, "image/avif;q=0.5,image/webp;q=0.9")
+	assert.Equal(t, "webp", obj.Transforms.FormatStr)
+
+	obj, _ = runFormatNegotiation(t, f, "image/avif;q=0,image/webp;q=0")
+	assert.Equal(t, "", obj.Transforms.FormatStr)
+	assert.False(t, strings.HasSuffix(obj.Key, "webp"))
+}
+
+func TestFormatNegotiationWildcard(t *testing.T) {
+	f := newFormatNegotiationPlugin(t, "")
+
+	obj, res := runFormatNegotiation(t, f, "image/*,*/*")
+	assert.Equal(t, "", obj.Transforms.FormatStr)
+	assert.Equal(t, "Accept", res.Headers.Get("Vary"))
+
+	obj, _ = runFormatNegotiation(t, f, "")
+	assert.Equal(t, "", obj.Transforms.FormatStr)
+}
+
+func TestFormatNegotiationOriginalFirst(t *testing.T) {
+	f := newFormatNegotiationPlugin(t, `
+formats: [original, webp]
+`)
+
+	obj, _ := runFormatNegotiation(t, f, "image/webp")
+	assert.Equal(t, "", obj.Transforms.FormatStr)
+}
+
+func TestFormatNegotiationInvalidConfig(t *testing.T) {
+	configs := []string{
+		"formats: [bmpx]",
+		"formats: webp",
+		"formats: [[webp]]",
+		"autoOnly: yes please",
+		"[webp]",
+	}
+
+	for _, configStr := range configs {
+		var cfg interface{}
+		err := yaml.Unmarshal([]byte(configStr), &cfg)
+		assert.Nil(t, err)
+
+		f := &FormatNegotiationPlugin{}
+		assert.NotNil(t, f.configure(cfg), configStr)
+	}
+}
+
+func TestFormatNegotiationInvalidConfigLoad(t *testing.T) {
+	mortConfig := config.Config{}
+	err := mortConfig.LoadFromString(`
+server:
+  plugins:
+    format-negotiation:
+      formats: [bmpx]
+buckets:
+  local:
+    storages:
+      basic:
+        kind: "local-meta"
+        rootPath: "/tmp"
+`)
+	assert.NotNil(t, err)
+}
+
+func TestNewPluginsManagerInvalidConfig(t *testing.T) {
+	assert.Panics(t, func() {
+		NewPluginsManager(map[string]interface{}{"format-negotiation": map[interface{}]interface{}{"formats": "webp"}})
+	})
+}
+
+func TestAddVaryNoDuplicates(t *testing.T) {
+	res := response.NewNoContent(200)
+	res.Headers.Add("Vary", "Accept-Encoding, accept")
+
+	addVary(res, "Accept")
+	assert.Len(t, res.Headers.Values("Vary"), 1)
+
+	addVary(res, "Sec-CH-DPR")
+	assert.Len(t, res.Headers.Values("Vary"), 2)
+}
+
+func TestFormatNegotiationAutoOnly(t *testing.T) {
+	f := newFormatNegotiationPlugin(t, `
+autoOnly: true
+`)
+
+	obj, res := runFormatNegotiation(t, f, "image/avif,image/webp,*/*")
+	assert.Equal(t, "", obj.Transforms.FormatStr)
+	assert.Equal(t, "", res.Headers.Get("Vary"))
+
+	req, _ := http.NewRequest("GET", "http://mort/local/small.jpg-m", nil)
+	req.Header.Add("Accept", "image/webp,*/*")
+	mortConfig := config.Config{}
+	err := mortConfig.Load("../benchmark/small.yml")
+	assert.Nil(t, err)
+
+	obj, err = object.NewFileObject(req.URL, &mortConfig)
+	assert.Nil(t, err)
+	obj.Transforms.AutoFormat()
+
+	res = response.NewNoContent(200)
+	res.Headers.Set("content-type", "image/jpg")
+	f.preProcess(obj, req)
+	f.postProcess(obj, req, res)
+	assert.Equal(t, "webp", obj.Transforms.FormatStr)
+	assert.Equal(t, "Accept", res.Headers.Get("Vary"))
+}
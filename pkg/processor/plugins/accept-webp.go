@@ -16,8 +16,8 @@ func init() {
 type WebpPlugin struct {
 }
 
-func (WebpPlugin) configure(_ interface{}) {
-
+func (WebpPlugin) configure(_ interface{}) error {
+	return nil
 }
 
 // PreProcess add webp transform to object
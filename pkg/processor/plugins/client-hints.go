@@ -0,0 +1,199 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/response"
+)
+
+const (
+	headerDPR           = "Sec-CH-DPR"
+	headerWidth         = "Sec-CH-Width"
+	headerViewportWidth = "Sec-CH-Viewport-Width"
+	clientHintsHeaders  = headerDPR + ", " + headerWidth + ", " + headerViewportWidth
+)
+
+// defaultBreakpoints widths used when none are configured, they bound number of variants of each image
+var defaultBreakpoints = []int{16, 32, 48, 64, 96, 128, 256, 384, 640, 750, 828, 1080, 1200, 1920, 2048, 3840}
+
+func init() {
+	RegisterPlugin("client-hints", &ClientHintsPlugin{})
+	config.RegisterPluginValidator("client-hints", func(cfg interface{}) error {
+		return (&ClientHintsPlugin{}).configure(cfg)
+	})
+}
+
+// ClientHintsPlugin plugin that adjust image dimensions to device using Client Hints headers
+type ClientHintsPlugin struct {
+	buckets     map[string]bool // buckets for which plugin is enabled, empty means all
+	breakpoints []int           // sorted list of allowed widths
+	maxDPR      float64
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func (c *ClientHintsPlugin) configure(config interface{}) error {
+	c.buckets = make(map[string]bool)
+	c.breakpoints = defaultBreakpoints
+	c.maxDPR = 3
+	if config == nil {
+		return nil
+	}
+
+	cfg, ok := config.(map[interface{}]interface{})
+	if !ok {
+		return errors.New("configuration should be a map")
+	}
+
+	if buckets, ok := cfg["buckets"]; ok {
+		bucketsList, ok := buckets.([]interface{})
+		if !ok {
+			return errors.New("buckets should be a list")
+		}
+
+		for _, b := range bucketsList {
+			bucket, ok := b.(string)
+			if !ok {
+				return fmt.Errorf("invalid bucket %v", b)
+			}
+			c.buckets[bucket] = true
+		}
+	}
+
+	if breakpoints, ok := cfg["breakpoints"]; ok {
+		breakpointsList, ok := breakpoints.([]interface{})
+		if !ok || len(breakpointsList) == 0 {
+			return errors.New("breakpoints should be a non empty list")
+		}
+
+		c.breakpoints = make([]int, 0, len(breakpointsList))
+		for _, b := range breakpointsList {
+			breakpoint, ok := b.(int)
+			if !ok || breakpoint < 1 {
+				return fmt.Errorf("invalid breakpoint %v", b)
+			}
+			c.breakpoints = append(c.breakpoints, breakpoint)
+		}
+		sort.Ints(c.breakpoints)
+	}
+
+	if maxDPR, ok := cfg["maxDpr"]; ok {
+		v, ok := toFloat(maxDPR)
+		if !ok || v < 1 {
+			return fmt.Errorf("invalid maxDpr %v", maxDPR)
+		}
+		c.maxDPR = v
+	}
+
+	return nil
+}
+
+func (c *ClientHintsPlugin) enabled(obj *object.FileObject) bool {
+	return obj.HasTransform() && (len(c.buckets) == 0 || c.buckets[obj.Bucket])
+}
+
+// preProcess scale transform dimensions using client hints
+func (c *ClientHintsPlugin) preProcess(obj *object.FileObject, req *http.Request) {
+	if !c.enabled(obj) {
+		return
+	}
+
+	width, height := obj.Transforms.Dimensions()
+	if width == 0 {
+		return
+	}
+
+	dpr := c.parseDPR(req.Header.Get(headerDPR))
+	target := int(math.Round(float64(width) * dpr))
+
+	// hinted widths are in physical pixels so image is never bigger than them
+	if hint := parseHintInt(req.Header.Get(headerWidth)); hint > 0 {
+		target = min(target, hint)
+	} else if hint := parseHintInt(req.Header.Get(headerViewportWidth)); hint > 0 {
+		target = min(target, int(math.Round(float64(hint)*dpr)))
+	}
+
+	if target == width {
+		return
+	}
+
+	target = c.snap(target)
+	if target == width {
+		return
+	}
+
+	if height != 0 {
+		height = int(math.Round(float64(height) * float64(target) / float64(width)))
+	}
+
+	// scaled transform has to fit into bucket limits as the one from request
+	scaled := obj.Transforms
+	if err := scaled.SetDimensions(target, height); err != nil {
+		return
+	}
+
+	if err := object.CheckLimits(obj.Limits, &scaled); err != nil {
+		return
+	}
+
+	obj.Transforms = scaled
+	obj.AppendToKey("ch" + strconv.Itoa(target))
+}
+
+// postProcess add client hints headers
+func (c *ClientHintsPlugin) postProcess(obj *object.FileObject, req *http.Request, res *response.Response) {
+	if res.IsImage() && c.enabled(obj) {
+		res.Headers.Set("Accept-CH", clientHintsHeaders)
+		addVary(res, headerDPR)
+		addVary(res, headerWidth)
+		addVary(res, headerViewportWidth)
+	}
+}
+
+func (c *ClientHintsPlugin) parseDPR(value string) float64 {
+	dpr, err := strconv.ParseFloat(value, 64)
+	if err != nil || dpr < 1 || math.IsNaN(dpr) {
+		return 1
+	}
+
+	return math.Min(dpr, c.maxDPR)
+}
+
+// snap returns smallest breakpoint that is not lower than width or the biggest one
+func (c *ClientHintsPlugin) snap(width int) int {
+	if len(c.breakpoints) == 0 {
+		return width
+	}
+
+	i := sort.SearchInts(c.breakpoints, width)
+	if i == len(c.breakpoints) {
+		return c.breakpoints[i-1]
+	}
+
+	return c.breakpoints[i]
+}
+
+func parseHintInt(value string) int {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < 0 {
+		return 0
+	}
+
+	return v
+}
@@ -0,0 +1,152 @@
+package plugins
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/mort/pkg/config"
+	"github.com/aldor007/mort/pkg/object"
+	"github.com/aldor007/mort/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func newClientHintsPlugin(t *testing.T, configStr string) *ClientHintsPlugin {
+	var cfg interface{}
+	err := yaml.Unmarshal([]byte(configStr), &cfg)
+	assert.Nil(t, err)
+
+	c := &ClientHintsPlugin{}
+	err = c.configure(cfg)
+	assert.Nil(t, err)
+	return c
+}
+
+func runClientHints(t *testing.T, c *ClientHintsPlugin, path string, headers map[string]string) (*object.FileObject, *response.Response) {
+	req, _ := http.NewRequest("GET", "http://mort"+path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	mortConfig := config.Config{}
+	err := mortConfig.Load("../benchmark/small.yml")
+	assert.Nil(t, err)
+
+	obj, err := object.NewFileObject(req.URL, &mortConfig)
+	assert.Nil(t, err)
+
+	res := response.NewNoContent(200)
+	res.Headers.Set("content-type", "image/jpg")
+
+	obj.Ctx = req.Context()
+	c.preProcess(obj, req)
+	c.postProcess(obj, req, res)
+	return obj, res
+}
+
+func TestClientHintsDPR(t *testing.T) {
+	c := newClientHintsPlugin(t, "")
+
+	obj, res := runClientHints(t, c, "/local/small.jpg-small", map[string]string{"Sec-CH-DPR": "2"})
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 256, width)
+	assert.Equal(t, 179, height)
+	assert.True(t, strings.HasSuffix(obj.Key, "ch256"))
+	assert.Equal(t, clientHintsHeaders, res.Headers.Get("Accept-CH"))
+	assert.Len(t, res.Headers.Values("Vary"), 3)
+
+	obj, _ = runClientHints(t, c, "/local/small.jpg-small", map[string]string{"Sec-CH-DPR": "10"})
+	width, _ = obj.Transforms.Dimensions()
+	assert.Equal(t, 384, width)
+}
+
+func TestClientHintsWidthBreakpoints(t *testing.T) {
+	c := newClientHintsPlugin(t, `
+breakpoints: [64, 128, 256]
+`)
+
+	obj, _ := runClientHints(t, c, "/local/small.jpg-m", map[string]string{"Sec-CH-DPR": "2", "Sec-CH-Width": "70"})
+	width, height := obj.Transforms.Dimensions()
+	assert.Equal(t, 128, width)
+	assert.Equal(t, 128, height)
+
+	obj, _ = runClientHints(t, c, "/local/small.jpg-m", map[string]string{"Sec-CH-DPR": "3"})
+	width, _ = obj.Transforms.Dimensions()
+	assert.Equal(t, 256, width)
+
+	obj, _ = runClientHints(t, c, "/local/small.jpg-m", map[string]string{"Sec-CH-Viewport-Width": "20"})
+	width, _ = obj.Transforms.Dimensions()
+	assert.Equal(t, 64, width)
+}
+
+func TestClientHintsNoHints(t *testing.T) {
+	c := newClientHintsPlugin(t, "")
+
+	obj, _ := runClientHints(t, c, "/local/small.jpg-m", nil)
+	width, _ := obj.Transforms.Dimensions()
+	assert.Equal(t, 100, width)
+	assert.False(t, strings.Contains(obj.Key, "ch"))
+}
+
+func TestClientHintsOtherBucket(t *testing.T) {
+	c := newClientHintsPlugin(t, `
+buckets: [media]
+`)
+
+	obj, res := runClientHints(t, c, "/local/small.jpg-m", map[string]string{"Sec-CH-DPR": "2"})
+	width, _ := obj.Transforms.Dimensions()
+	assert.Equal(t, 100, width)
+	assert.Equal(t, "", res.Headers.Get("Accept-CH"))
+}
+
+func TestClientHintsLimits(t *testing.T) {
+	c := newClientHintsPlugin(t, `
+breakpoints: [100, 200, 400]
+`)
+
+	req, _ := http.NewRequest("GET", "http://mort/local/small.jpg-m", nil)
+	req.Header.Set("Sec-CH-DPR", "2")
+
+	mortConfig := config.Config{}
+	err := mortConfig.Load("../benchmark/small.yml")
+	assert.Nil(t, err)
+
+	obj, err := object.NewFileObject(req.URL, &mortConfig)
+	assert.Nil(t, err)
+	key := obj.Key
+	hash := obj.Transforms.HashStr()
+
+	obj.Limits = &config.Limits{MaxArea: 150 * 150}
+	c.preProcess(obj, req)
+	width, _ := obj.Transforms.Dimensions()
+	assert.Equal(t, 100, width)
+	assert.Equal(t, key, obj.Key)
+	assert.Equal(t, hash, obj.Transforms.HashStr())
+
+	obj.Limits = &config.Limits{Widths: []int{100, 200}}
+	c.preProcess(obj, req)
+	width, _ = obj.Transforms.Dimensions()
+	assert.Equal(t, 200, width)
+}
+
+func TestClientHintsInvalidConfig(t *testing.T) {
+	configs := []string{
+		"buckets: media",
+		"buckets: [[media]]",
+		"breakpoints: 320",
+		"breakpoints: [320, wide]",
+		"breakpoints: []",
+		"maxDpr: 0.5",
+		"[media]",
+	}
+
+	for _, configStr := range configs {
+		var cfg interface{}
+		err := yaml.Unmarshal([]byte(configStr), &cfg)
+		assert.Nil(t, err)
+
+		c := &ClientHintsPlugin{}
+		assert.NotNil(t, c.configure(cfg), configStr)
+	}
+}
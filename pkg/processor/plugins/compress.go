@@ -51,7 +51,7 @@ func parseConfig(cType *compressConfig, cfg interface{}) {
 	cType.enabled = true
 }
 
-func (c *CompressPlugin) configure(config interface{}) {
+func (c *CompressPlugin) configure(config interface{}) error {
 	cfg := config.(map[interface{}]interface{})
 
 	if tmpCfg, ok := cfg["brotli"]; ok {
@@ -65,6 +65,8 @@ func (c *CompressPlugin) configure(config interface{}) {
 	if tmpCfg, ok := cfg["zstd"]; ok {
 		parseConfig(&c.zstd, tmpCfg)
 	}
+
+	return nil
 }
 
 // PreProcess add webp transform to object
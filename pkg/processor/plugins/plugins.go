@@ -3,6 +3,7 @@ package plugins
 import (
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/aldor007/mort/pkg/monitoring"
 	"github.com/aldor007/mort/pkg/object"
@@ -17,7 +18,7 @@ var pluginsList = make(map[string]Plugin)
 type Plugin interface {
 	preProcess(obj *object.FileObject, req *http.Request)                          // PreProcess is used before start of processing object
 	postProcess(obj *object.FileObject, req *http.Request, res *response.Response) // PostProcess is used after end of processing object
-	configure(config interface{})
+	configure(config interface{}) error                                            // configure is used to set up plugin, error is returned for invalid configuration
 }
 
 // PluginsManager process plugins
@@ -28,16 +29,23 @@ type PluginsManager struct {
 // NewPluginsManager create new instance of plugins manager
 func NewPluginsManager(plugins map[string]interface{}) PluginsManager {
 	pm := PluginsManager{}
-	pm.list = make([]string, 0)
-	for pName, pConfig := range plugins {
+	pm.list = make([]string, 0, len(plugins))
+	for pName := range plugins {
 		if _, ok := pluginsList[pName]; !ok {
 			panic(fmt.Errorf("unknown plugin %s", pName))
 		}
+		pm.list = append(pm.list, pName)
+	}
 
+	// plugins can change object key so they have to run always in the same order
+	sort.Strings(pm.list)
+	for _, pName := range pm.list {
 		monitoring.Log().Info("Plugin manager configuring", zap.String("pluginName", pName))
-		pluginsList[pName].configure(pConfig)
-		pm.list = append(pm.list, pName)
+		if err := pluginsList[pName].configure(plugins[pName]); err != nil {
+			panic(fmt.Errorf("plugin %s has invalid configuration: %w", pName, err))
+		}
 	}
+
 	return pm
 }
 
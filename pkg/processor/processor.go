@@ -18,7 +18,9 @@ import (
 	"github.com/aldor007/mort/pkg/monitoring"
 	"github.com/aldor007/mort/pkg/object"
 	_ "github.com/aldor007/mort/pkg/object/cloudinary"
+	_ "github.com/aldor007/mort/pkg/object/imgproxy"
 	_ "github.com/aldor007/mort/pkg/object/tengo"
+	_ "github.com/aldor007/mort/pkg/object/thumbor"
 	"github.com/aldor007/mort/pkg/processor/plugins"
 	"github.com/aldor007/mort/pkg/response"
 	"github.com/aldor007/mort/pkg/storage"
@@ -29,6 +31,9 @@ import (
 
 const s3LocationStr = "<?xml version=\"1.0\" encoding=\"UTF-8\"?><LocationConstraint xmlns=\"http://s3.amazonaws.com/doc/2006-03-01/\">EU</LocationConstraint>"
 
+// maxUploadSize default limit of uploaded image which is loaded to memory to filter its metadata
+const maxUploadSize = 100 << 20
+
 var (
 	errTimeout       = errors.New("timeout")         // error when timeout
 	errContextCancel = errors.New("context timeout") // error when context timeout
@@ -238,7 +243,7 @@ func (r *RequestProcessor) process(req *http.Request, obj *object.FileObject) *r
 		return get()
 	case "PUT":
 		go r.responseCache.Delete(obj)
-		return handlePUT(req, obj)
+		return handlePUT(req, obj, r.serverConfig.MaxFileSize)
 	case "DELETE":
 		go r.responseCache.Delete(obj)
 		return storage.Delete(obj)
@@ -249,11 +254,50 @@ func (r *RequestProcessor) process(req *http.Request, obj *object.FileObject) *r
 
 }
 
-func handlePUT(req *http.Request, obj *object.FileObject) *response.Response {
+func handlePUT(req *http.Request, obj *object.FileObject, maxSize int64) *response.Response {
 	defer req.Body.Close()
+	if bucket, ok := config.GetInstance().Buckets[obj.Bucket]; ok && bucket.Metadata != nil && bucket.Metadata.OnUpload && !obj.HasTransform() {
+		if maxSize == 0 {
+			maxSize = maxUploadSize
+		}
+		return handlePUTWithMetadataPolicy(req, obj, bucket.Metadata.MetadataPolicy, maxSize)
+	}
+
 	return storage.Set(obj, req.Header, req.ContentLength, req.Body)
 }
 
+// handlePUTWithMetadataPolicy store uploaded image without metadata which are not allowed by bucket policy
+// image is read to memory, so uploads larger than maxSize are rejected
+func handlePUTWithMetadataPolicy(req *http.Request, obj *object.FileObject, policy config.MetadataPolicy, maxSize int64) *response.Response {
+	errTooLarge := errors.New("uploaded file is larger than " + strconv.FormatInt(maxSize, 10) + " bytes")
+	if req.ContentLength > maxSize {
+		return response.NewError(413, errTooLarge)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxSize+1))
+	if err != nil {
+		return response.NewError(400, err)
+	}
+
+	if int64(len(body)) > maxSize {
+		return response.NewError(413, errTooLarge)
+	}
+
+	body, changed, err := engine.FilterUploadMetadata(body, policy.Policy, policy.Tags)
+	if err != nil {
+		monitoring.Log().Error("Processor unable to filter metadata of uploaded image", obj.LogData(zap.Error(err))...)
+		return response.NewError(500, err)
+	}
+
+	// checksums sent by client don't match modified image
+	if changed {
+		req.Header.Del("Content-MD5")
+		req.Header.Del("ETag")
+	}
+
+	return storage.Set(obj, req.Header, int64(len(body)), bytes.NewReader(body))
+}
+
 func (r *RequestProcessor) collapseGET(req *http.Request, obj *object.FileObject) *response.Response {
 	ctx := obj.Ctx
 	lockResult, locked := r.collapse.Lock(ctx, obj.Key)
@@ -440,7 +484,7 @@ func (r *RequestProcessor) handleNotFound(obj, parentObj *object.FileObject, tra
 		return parentRes
 	}
 	parentRes.Close()
-	if parentRes.StatusCode != 200 || !parentRes.IsImage() {
+	if parentRes.StatusCode != 200 || !(parentRes.IsImage() || parentRes.IsDocument()) {
 		return res
 	}
 	if cacheRes, errCache := r.responseCache.Get(parentObj); errCache == nil {
@@ -12,7 +12,9 @@ import (
 	"github.com/aldor007/mort/pkg/throttler"
 	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -461,6 +463,27 @@ func TestPut(t *testing.T) {
 	assert.Equal(t, res.StatusCode, 200)
 }
 
+func TestPutWithMetadataPolicyTooLarge(t *testing.T) {
+	req, _ := http.NewRequest("PUT", "http://mort/local/file-test", strings.NewReader("aaaa"))
+
+	mortConfig := config.Config{}
+	err := mortConfig.Load("./benchmark/small.yml")
+	assert.Nil(t, err)
+
+	obj, err := object.NewFileObject(req.URL, &mortConfig)
+	assert.Nil(t, err)
+
+	policy := config.MetadataPolicy{Policy: "strip"}
+	res := handlePUTWithMetadataPolicy(req, obj, policy, 3)
+	assert.Equal(t, 413, res.StatusCode)
+
+	// body without content length is limited while reading
+	req, _ = http.NewRequest("PUT", "http://mort/local/file-test", io.NopCloser(strings.NewReader("aaaa")))
+	req.ContentLength = -1
+	res = handlePUTWithMetadataPolicy(req, obj, policy, 3)
+	assert.Equal(t, 413, res.StatusCode)
+}
+
 func TestS3GET(t *testing.T) {
 	req, _ := http.NewRequest("GET", "http://mort/local?maker=&max-keys=1000&delimter=&prefix=", nil)
 	ctx := req.Context()
@@ -441,6 +441,11 @@ func (r *Response) IsImage() bool {
 	return strings.Contains(r.Headers.Get(HeaderContentType), "image/")
 }
 
+// IsDocument check if response is document which can be rasterized (e.g. PDF)
+func (r *Response) IsDocument() bool {
+	return strings.Contains(r.Headers.Get(HeaderContentType), "application/pdf")
+}
+
 func (r *Response) writeDebug() {
 	if !r.debug {
 		return
@@ -202,6 +202,17 @@ func TestResponse_IsImage(t *testing.T) {
 	assert.True(t, res.IsImage())
 }
 
+func TestResponse_IsDocument(t *testing.T) {
+	res := NewNoContent(200)
+
+	res.Headers.Set("Content-type", "application/pdf")
+	assert.True(t, res.IsDocument())
+	assert.False(t, res.IsImage())
+
+	res.Headers.Set("Content-type", "image/tiff")
+	assert.False(t, res.IsDocument())
+}
+
 func TestIsRangeOrCond(t *testing.T) {
 	req, _ := http.NewRequest("GET", "http://url", nil)
 	req.Header.Set("Range", "1-3")
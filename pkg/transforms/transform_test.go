@@ -3,6 +3,7 @@ package transforms
 import (
 	"github.com/h2non/bimg"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"strconv"
 	"testing"
 )
@@ -194,6 +195,747 @@ func TestTransformsFormat(t *testing.T) {
 
 }
 
+func TestTransformsFormatAvif(t *testing.T) {
+	trans := Transforms{}
+	assert.Nil(t, trans.Format("avif"))
+
+	optsArr, err := trans.BimgOptions(ImageInfo{})
+	assert.Nil(t, err)
+	opts := optsArr[0]
+	assert.Equal(t, opts.Type, bimg.AVIF)
+	assert.Equal(t, opts.Speed, 5)
+	assert.False(t, opts.Lossless)
+
+	_, encode := trans.Encoder("jpeg")
+	assert.False(t, encode)
+
+	trans2 := Transforms{}
+	trans2.Format("avif")
+	assert.Nil(t, trans2.Speed(0))
+	assert.Nil(t, trans2.Lossless())
+	assert.NotEqual(t, trans.HashStr(), trans2.HashStr())
+
+	optsArr, err = trans2.BimgOptions(ImageInfo{})
+	assert.Nil(t, err)
+	assert.Equal(t, optsArr[0].Speed, 0)
+	assert.True(t, optsArr[0].Lossless)
+
+	assert.NotNil(t, trans2.Speed(9))
+	assert.NotNil(t, trans2.Speed(-1))
+}
+
+func TestTransformsFormatJxl(t *testing.T) {
+	trans := Transforms{}
+	assert.Nil(t, trans.Format("jxl"))
+	assert.Nil(t, trans.Effort(4))
+	trans.Quality(80)
+
+	optsArr, err := trans.BimgOptions(ImageInfo{})
+	assert.Nil(t, err)
+	assert.Equal(t, optsArr[0].Type, bimg.PNG)
+
+	enc, encode := trans.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, enc.Format, "jxl")
+	assert.Equal(t, enc.Effort, 4)
+	assert.Equal(t, enc.Quality, 80)
+	assert.False(t, enc.Lossless)
+
+	trans2 := Transforms{}
+	trans2.Format("jxl")
+	trans2.Effort(5)
+	trans2.Quality(80)
+	assert.NotEqual(t, trans.HashStr(), trans2.HashStr())
+
+	assert.NotNil(t, trans.Effort(0))
+	assert.NotNil(t, trans.Effort(10))
+}
+
+func TestTransformsFormatPlaceholder(t *testing.T) {
+	for _, format := range []string{"blurhash", "lqip"} {
+		trans := Transforms{}
+		assert.Nil(t, trans.Format(format))
+
+		optsArr, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+		assert.Nil(t, err)
+		assert.Equal(t, optsArr[0].Type, bimg.PNG)
+
+		enc, encode := trans.Encoder("jpeg")
+		assert.True(t, encode)
+		assert.Equal(t, enc.Format, format)
+	}
+
+	blurhash := Transforms{}
+	blurhash.Format("blurhash")
+	lqip := Transforms{}
+	lqip.Format("lqip")
+	assert.NotEqual(t, blurhash.HashStr(), lqip.HashStr())
+}
+
+func TestTransformsMergeEncoder(t *testing.T) {
+	trans := Transforms{}
+	trans.Resize(100, 100, false, false, false)
+
+	other := Transforms{}
+	other.Format("jxl")
+	other.Effort(3)
+	other.Lossless()
+
+	assert.Nil(t, trans.Merge(other))
+	enc, encode := trans.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, enc.Effort, 3)
+	assert.True(t, enc.Lossless)
+}
+
+func TestTransformsEncoderOptions(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.JpegOptions(false, "420", false, 0))
+	assert.NotNil(t, trans.JpegOptions(false, "", false, 9))
+	assert.NotNil(t, trans.PngOptions(false, 10, 0))
+	assert.NotNil(t, trans.PngOptions(false, 0, 1))
+	assert.NotNil(t, trans.PngOptions(false, 0, 100))
+	assert.NotNil(t, trans.PngOptions(false, -1, 0))
+	assert.NotNil(t, trans.WebpOptions(false, -1, false))
+	assert.NotNil(t, trans.WebpOptions(false, 7, false))
+
+	// progressive jpeg is supported by bimg
+	assert.Nil(t, trans.JpegOptions(true, "", false, 0))
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.True(t, opts[0].Interlace)
+	assert.Equal(t, bimg.UNKNOWN, opts[0].Type)
+	_, encode := trans.Encoder("jpeg")
+	assert.False(t, encode)
+
+	// jpeg options are not used for png output
+	opts, _ = trans.BimgOptions(ImageInfo{format: "png"})
+	assert.False(t, opts[0].Interlace)
+
+	jpeg := New()
+	assert.Nil(t, jpeg.JpegOptions(true, "off", true, 3))
+	assert.NotEqual(t, jpeg.HashStr(), trans.HashStr())
+	opts, _ = jpeg.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Equal(t, bimg.PNG, opts[0].Type)
+	enc, encode := jpeg.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, "jpeg", enc.Format)
+	assert.Equal(t, "off", enc.Subsample)
+	assert.True(t, enc.Trellis)
+	assert.True(t, enc.Interlace)
+	assert.Equal(t, 3, enc.QuantTable)
+
+	png := New()
+	assert.Nil(t, png.PngOptions(true, 9, 0))
+	opts, _ = png.BimgOptions(ImageInfo{format: "png"})
+	assert.True(t, opts[0].Palette)
+	assert.Equal(t, 9, opts[0].Compression)
+	_, encode = png.Encoder("png")
+	assert.False(t, encode)
+
+	assert.Nil(t, png.PngOptions(false, 0, 16))
+	enc, encode = png.Encoder("png")
+	assert.True(t, encode)
+	assert.True(t, enc.Palette)
+	assert.Equal(t, 16, enc.Colors)
+
+	webp := New()
+	assert.Nil(t, webp.Format("webp"))
+	assert.Nil(t, webp.WebpOptions(true, 6, true))
+	opts, _ = webp.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Equal(t, bimg.PNG, opts[0].Type)
+	enc, encode = webp.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, "webp", enc.Format)
+	assert.Equal(t, 6, enc.Effort)
+	assert.True(t, enc.NearLossless)
+
+	merged := New()
+	merged.Resize(100, 0, false, false, false)
+	assert.Nil(t, merged.Merge(jpeg))
+	enc, encode = merged.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, "off", enc.Subsample)
+}
+
+func TestTransformsMaxBytes(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.MaxBytes(0, false))
+
+	assert.Nil(t, trans.MaxBytes(10000, false))
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.PNG, opts[0].Type)
+
+	enc, encode := trans.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, "jpeg", enc.Format)
+	assert.Equal(t, 10000, enc.MaxBytes)
+	assert.False(t, enc.Downscale)
+
+	// formats which can't be searched are not changed
+	_, encode = trans.Encoder("gif")
+	assert.False(t, encode)
+
+	downscale := New()
+	assert.Nil(t, downscale.MaxBytes(10000, true))
+	assert.NotEqual(t, downscale.HashStr(), trans.HashStr())
+
+	jxl := New()
+	jxl.Format("jxl")
+	jxl.MaxBytes(5000, true)
+	enc, encode = jxl.Encoder("jpeg")
+	assert.True(t, encode)
+	assert.Equal(t, 5000, enc.MaxBytes)
+	assert.True(t, enc.Downscale)
+
+	merged := New()
+	merged.Resize(100, 0, false, false, false)
+	assert.Nil(t, merged.Merge(downscale))
+	enc, encode = merged.Encoder("webp")
+	assert.True(t, encode)
+	assert.Equal(t, 10000, enc.MaxBytes)
+}
+
+func TestTransformsSetDimensions(t *testing.T) {
+	trans := Transforms{}
+	assert.NotNil(t, trans.SetDimensions(10, 10))
+
+	trans.Resize(100, 50, false, false, false)
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.SetDimensions(200, 100))
+
+	width, height := trans.Dimensions()
+	assert.Equal(t, 200, width)
+	assert.Equal(t, 100, height)
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	assert.NotNil(t, trans.SetDimensions(-1, 100))
+}
+
+func TestTransformsSummary(t *testing.T) {
+	trans := New()
+	trans.Crop(100, 50, "smart", false, false)
+	trans.Quality(80)
+	trans.Grayscale()
+
+	summary := trans.Summary()
+	assert.Equal(t, 100, summary.Width)
+	assert.Equal(t, 50, summary.Height)
+	assert.Equal(t, 80, summary.Quality)
+	assert.Equal(t, []string{"crop", "grayscale"}, summary.Operations)
+
+	trans = New()
+	trans.ResizeCropAuto(30, 40)
+	summary = trans.Summary()
+	assert.Equal(t, 30, summary.Width)
+	assert.Equal(t, []string{"resizeCropAuto"}, summary.Operations)
+}
+
+func TestTransformsSummaryAutoQuality(t *testing.T) {
+	trans := New()
+	assert.Nil(t, trans.AutoQuality("best"))
+	assert.Equal(t, 90, trans.Summary().Quality)
+
+	trans.Format("avif")
+	assert.Equal(t, 75, trans.Summary().Quality)
+
+	trans.Quality(50)
+	assert.Equal(t, 50, trans.Summary().Quality)
+}
+
+func TestTransformsGravityBackground(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.Gravity("unknown"))
+	assert.Nil(t, trans.Gravity("north"))
+	assert.NotNil(t, trans.Background("#12345"))
+	assert.NotNil(t, trans.Background("zzzzzz"))
+	assert.Nil(t, trans.Background("#f00"))
+	trans.Resize(100, 100, false, false, false)
+
+	opts, err := trans.BimgOptions(ImageInfo{width: 200, height: 200})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.GravityNorth, opts[0].Gravity)
+	assert.Equal(t, bimg.Color{R: 255}, opts[0].Background)
+
+	trans = New()
+	assert.Nil(t, trans.Background("white"))
+	opts, _ = trans.BimgOptions(ImageInfo{})
+	assert.Equal(t, bimg.Color{R: 255, G: 255, B: 255}, opts[0].Background)
+}
+
+func TestTransformsFocalPoint(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.FocalPoint(-1, 0.5))
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.FocalPoint(0.9, 0.5))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	trans.Crop(100, 100, "", false, false)
+
+	opts, err := trans.BimgOptions(ImageInfo{width: 400, height: 200})
+	assert.Nil(t, err)
+	assert.Len(t, opts, 2)
+	assert.Equal(t, 200, opts[0].AreaWidth)
+	assert.Equal(t, 200, opts[0].AreaHeight)
+	assert.Equal(t, 200, opts[0].Left)
+	assert.Equal(t, 0, opts[0].Top)
+	assert.Equal(t, 100, opts[1].Width)
+
+	trans = New()
+	trans.Crop(100, 100, "", false, false)
+	trans.FocalPoint(120, 100)
+	opts, _ = trans.BimgOptions(ImageInfo{width: 400, height: 200})
+	assert.Equal(t, 20, opts[0].Left)
+
+	trans = New()
+	trans.ResizeCropAuto(100, 100)
+	trans.FocalPoint(1, 0.5)
+	left, top, width, height := trans.calculateAutoCrop(ImageInfo{width: 400, height: 200})
+	assert.Equal(t, []int{200, 0, 200, 200}, []int{left, top, width, height})
+}
+
+func TestTransformsText(t *testing.T) {
+	trans := New()
+	_, ok := trans.TextOverlay()
+	assert.False(t, ok)
+	assert.NotNil(t, trans.Text("", "", 0, "", "top-left", 1, 0))
+	assert.NotNil(t, trans.Text("caption", "", 0, "", "left-top", 1, 0))
+	assert.NotNil(t, trans.Text("caption", "", 0, "", "top-left", 2, 0))
+	assert.NotNil(t, trans.Text("caption", "", 0, "#zzz", "top-left", 1, 0))
+
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.Text("caption", "", 0, "white", "bottom-right", 0.5, 0))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	assert.Equal(t, []string{"text"}, trans.Summary().Operations)
+
+	overlay, ok := trans.TextOverlay()
+	assert.True(t, ok)
+	assert.Equal(t, TextOverlay{Text: "caption", Font: "sans 24", DPI: 72, Color: bimg.Color{R: 255, G: 255, B: 255},
+		XAlign: 1, YAlign: 1, Opacity: 0.5}, overlay)
+
+	other := New()
+	other.Text("other", "serif", 10, "", "center-center", 1, 0)
+	assert.NotEqual(t, other.HashStr(), trans.HashStr())
+	assert.NotNil(t, trans.Merge(other))
+}
+
+func TestTransformsExtend(t *testing.T) {
+	trans := New()
+	_, ok := trans.ExtendOptions()
+	assert.False(t, ok)
+	assert.NotNil(t, trans.Extend(0, 100, "", ""))
+	assert.NotNil(t, trans.Extend(100, 100, "", "mirror"))
+	assert.NotNil(t, trans.Extend(100, 100, "#zzzzzzzz", ""))
+
+	assert.Nil(t, trans.Extend(200, 100, "", ""))
+	ext, ok := trans.ExtendOptions()
+	assert.True(t, ok)
+	assert.Equal(t, ExtendOptions{Width: 200, Height: 100, Color: bimg.Color{R: 255, G: 255, B: 255}, Alpha: 255}, ext)
+	assert.Equal(t, []string{"extend"}, trans.Summary().Operations)
+	assert.Equal(t, 200, trans.Summary().Width)
+
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.Extend(200, 100, "transparent", "color"))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	ext, _ = trans.ExtendOptions()
+	assert.Equal(t, uint8(0), ext.Alpha)
+
+	assert.Nil(t, trans.Extend(200, 100, "#ff000080", ""))
+	ext, _ = trans.ExtendOptions()
+	assert.Equal(t, ExtendOptions{Width: 200, Height: 100, Color: bimg.Color{R: 255}, Alpha: 128}, ext)
+
+	blur := New()
+	assert.Nil(t, blur.Extend(200, 100, "", "blur"))
+	ext, _ = blur.ExtendOptions()
+	assert.Equal(t, float64(defaultExtendBlur), ext.Blur)
+	assert.NotEqual(t, blur.HashStr(), trans.HashStr())
+
+	opts, err := blur.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.JPEG, opts[0].Type)
+
+	merged := New()
+	assert.Nil(t, merged.Merge(blur))
+	_, ok = merged.ExtendOptions()
+	assert.True(t, ok)
+}
+
+func TestTransformsAdjustments(t *testing.T) {
+	trans := New()
+	_, ok := trans.Adjustments()
+	assert.False(t, ok)
+	assert.NotNil(t, trans.Sharpen(0, 0, 0))
+	assert.NotNil(t, trans.Sharpen(1, -1, 0))
+	assert.NotNil(t, trans.Modulate(-1, 0, 0, 0))
+	assert.NotNil(t, trans.Modulate(1, 1, 11, 0))
+	assert.NotNil(t, trans.Modulate(1, 1, 1, 400))
+	assert.NotNil(t, trans.Gamma(0))
+	assert.NotNil(t, trans.Tint("nocolor", ""))
+	assert.NotNil(t, trans.Tint("red", "nocolor"))
+	assert.False(t, trans.NotEmpty)
+
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.Sharpen(1.5, 0, 0))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	hashStr = trans.HashStr()
+	assert.Nil(t, trans.Modulate(1.2, 0, 0.5, 90))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	hashStr = trans.HashStr()
+	assert.Nil(t, trans.Tint("#ff0000", ""))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	hashStr = trans.HashStr()
+	assert.Nil(t, trans.Gamma(2.2))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	assert.Equal(t, []string{"sharpen", "modulate", "gamma", "tint"}, trans.Summary().Operations)
+
+	adj, ok := trans.Adjustments()
+	assert.True(t, ok)
+	assert.Equal(t, Adjustments{SharpenSigma: 1.5, SharpenJagged: defaultSharpenJagged, Modulate: true, Brightness: 1.2,
+		Contrast: 1, Saturation: 0.5, Hue: 90, Tint: true, TintColor: bimg.Color{R: 255}}, adj)
+
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2.2, opts[0].Gamma)
+
+	other := New()
+	other.Modulate(0, 0, 0, 10)
+	assert.NotEqual(t, other.HashStr(), trans.HashStr())
+
+	merged := New()
+	assert.Nil(t, merged.Merge(trans))
+	adj, ok = merged.Adjustments()
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, adj.SharpenSigma)
+	assert.True(t, adj.Tint)
+}
+
+func TestTransformsTrimZoom(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.Trim(-1, ""))
+	assert.NotNil(t, trans.Trim(10, "nocolor"))
+	assert.NotNil(t, trans.Zoom(0))
+	assert.NotNil(t, trans.Zoom(maxZoom+1))
+	assert.False(t, trans.NotEmpty)
+
+	assert.Nil(t, trans.Trim(0, ""))
+	hashStr := trans.HashStr()
+	assert.Nil(t, trans.Trim(0, "black"))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	hashStr = trans.HashStr()
+	assert.Nil(t, trans.Resize(100, 50, false, false, false))
+	assert.Nil(t, trans.Zoom(3))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+
+	summary := trans.Summary()
+	assert.Equal(t, []string{"resize", "trim", "zoom"}, summary.Operations)
+	assert.Equal(t, 300, summary.Width)
+	assert.Equal(t, 150, summary.Height)
+
+	trans.Format("png")
+	opts, err := trans.BimgOptions(ImageInfo{width: 400, height: 200, format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Len(t, opts, 3)
+	assert.Equal(t, bimg.Options{Trim: true, Threshold: defaultTrimThreshold}, opts[0])
+	assert.Equal(t, 100, opts[1].Width)
+	assert.Equal(t, 2, opts[2].Zoom)
+	assert.Equal(t, bimg.PNG, opts[2].Type)
+
+	merged := New()
+	assert.Nil(t, merged.Merge(trans))
+	assert.Equal(t, trans.Summary(), merged.Summary())
+}
+
+func TestTransformsAnimation(t *testing.T) {
+	trans := New()
+	assert.Nil(t, trans.Resize(100, 0, false, false, false))
+	anim, ok := trans.Animation("gif")
+	assert.True(t, ok)
+	assert.Equal(t, Animation{Width: 100, MaxFrames: DefaultMaxFrames, Format: "gif"}, anim)
+
+	_, ok = trans.Animation("jpeg")
+	assert.False(t, ok)
+
+	hashStr := trans.HashStr()
+	assert.NotNil(t, trans.MaxFrames(0))
+	assert.Nil(t, trans.MaxFrames(10))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	trans.Format("webp")
+	trans.Quality(70)
+	anim, ok = trans.Animation("gif")
+	assert.True(t, ok)
+	assert.Equal(t, Animation{Width: 100, MaxFrames: 10, Format: "webp", Quality: 70}, anim)
+
+	crop := New()
+	crop.Crop(50, 50, "north", false, false)
+	_, ok = crop.Animation("gif")
+	assert.False(t, ok)
+	crop.Crop(50, 50, "center", false, false)
+	anim, ok = crop.Animation("gif")
+	assert.True(t, ok)
+	assert.True(t, anim.Crop)
+
+	blur := New()
+	blur.Blur(2, 0)
+	_, ok = blur.Animation("gif")
+	assert.False(t, ok)
+}
+
+func TestTransformsFrame(t *testing.T) {
+	trans := New()
+	_, ok := trans.FrameIndex()
+	assert.False(t, ok)
+	assert.NotNil(t, trans.Frame(-1))
+
+	assert.Nil(t, trans.Frame(2))
+	frame, ok := trans.FrameIndex()
+	assert.True(t, ok)
+	assert.Equal(t, 2, frame)
+	assert.Equal(t, []string{"frame"}, trans.Summary().Operations)
+	_, ok = trans.Animation("gif")
+	assert.False(t, ok)
+
+	opts, err := trans.BimgOptions(ImageInfo{format: "gif"})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.GIF, opts[0].Type)
+
+	other := New()
+	other.Frame(1)
+	assert.NotEqual(t, other.HashStr(), trans.HashStr())
+}
+
+func TestTransformsMaxRasterArea(t *testing.T) {
+	trans := New()
+	trans.DPI(150)
+	hashStr := trans.HashStr()
+	assert.Equal(t, DefaultMaxRasterArea, trans.RasterArea())
+	assert.NotNil(t, trans.MaxRasterArea(0))
+
+	assert.Nil(t, trans.MaxRasterArea(1000000))
+	assert.Equal(t, 1000000, trans.RasterArea())
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	assert.Equal(t, 150, trans.Summary().DPI)
+
+	// resolution of text is limited too
+	assert.Nil(t, trans.Text("mort", "", 0, "", "top-left", 1, 300))
+	assert.Equal(t, 300, trans.Summary().DPI)
+}
+
+func TestTransformsDPI(t *testing.T) {
+	trans := New()
+	assert.Equal(t, 0, trans.DocumentDPI())
+	assert.NotNil(t, trans.DPI(0))
+	assert.NotNil(t, trans.DPI(maxDPI+1))
+
+	assert.Nil(t, trans.DPI(150))
+	assert.Equal(t, 150, trans.DocumentDPI())
+	assert.True(t, trans.NotEmpty)
+
+	other := New()
+	other.DPI(300)
+	assert.NotEqual(t, other.HashStr(), trans.HashStr())
+
+	// documents are not encoded in source format
+	trans.Frame(1)
+	opts, err := trans.BimgOptions(ImageInfo{format: "pdf"})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.UNKNOWN, opts[0].Type)
+
+	merged := New()
+	assert.Nil(t, merged.Merge(trans))
+	assert.Equal(t, 150, merged.DocumentDPI())
+}
+
+func TestTransformsInfo(t *testing.T) {
+	trans := New()
+	assert.False(t, trans.HasInfo())
+
+	trans.Info()
+	assert.True(t, trans.HasInfo())
+	assert.True(t, trans.NotEmpty)
+	assert.Contains(t, trans.Summary().Operations, "info")
+	empty := New()
+	assert.NotEqual(t, empty.HashStr(), trans.HashStr())
+
+	// metadata describes result of previous transforms so it is never merged
+	resize := New()
+	resize.Resize(100, 0, false, false, false)
+	assert.NotNil(t, resize.Merge(trans))
+	assert.Len(t, Merge([]Transforms{resize, trans}), 2)
+}
+
+func TestTransformsMask(t *testing.T) {
+	trans := New()
+	_, ok, err := trans.MaskOptions()
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	assert.NotNil(t, trans.Mask("star", 0, ""))
+	assert.NotNil(t, trans.Mask("rounded", 0, ""))
+	assert.NotNil(t, trans.Mask("image", 0, ""))
+	assert.NotNil(t, trans.Mask("", 0, ""))
+
+	assert.Nil(t, trans.Mask("rounded", 20, ""))
+	mask, ok, err := trans.MaskOptions()
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "rounded", mask.Shape)
+	assert.Equal(t, 20, mask.Radius)
+	assert.Contains(t, trans.Summary().Operations, "mask")
+
+	circle := New()
+	circle.Mask("circle", 0, "")
+	assert.NotEqual(t, circle.HashStr(), trans.HashStr())
+
+	// image mask is used when only image is given
+	image := New()
+	assert.Nil(t, image.Mask("", 0, "mask.png"))
+	assert.Equal(t, "image", image.mask.shape)
+}
+
+func TestTransformsMaskFormat(t *testing.T) {
+	tests := []struct {
+		source   string
+		format   string
+		expected string
+	}{
+		{"jpeg", "", "png"},
+		{"png", "", "png"},
+		{"webp", "", "webp"},
+		{"png", "jpeg", "png"},
+		{"jpeg", "webp", "webp"},
+		{"jpeg", "avif", "avif"},
+		{"gif", "", "png"},
+	}
+
+	for _, tt := range tests {
+		trans := New()
+		trans.Mask("circle", 0, "")
+		if tt.format != "" {
+			trans.Format(tt.format)
+		}
+
+		// bimg returns lossless image for mask, output format is encoded by engine
+		opts, err := trans.BimgOptions(ImageInfo{format: tt.source, width: 100, height: 100})
+		assert.Nil(t, err)
+		assert.Equal(t, bimg.PNG, opts[0].Type, tt.source+" -> "+tt.format)
+
+		enc, ok := trans.Encoder(tt.source)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, enc.Format, tt.source+" -> "+tt.format)
+	}
+}
+
+func TestTransformsPostProcessedEncoder(t *testing.T) {
+	trans := New()
+	trans.Quality(70)
+	_, ok := trans.Encoder("jpeg")
+	assert.False(t, ok)
+
+	assert.Nil(t, trans.Sharpen(1, 0, 0))
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.PNG, opts[0].Type)
+
+	enc, ok := trans.Encoder("jpeg")
+	assert.True(t, ok)
+	assert.Equal(t, "jpeg", enc.Format)
+	assert.Equal(t, 70, enc.Quality)
+
+	// max bytes is used only for formats supported by budget search
+	assert.Nil(t, trans.MaxBytes(1000, false))
+	enc, _ = trans.Encoder("gif")
+	assert.Equal(t, "gif", enc.Format)
+	assert.Equal(t, 0, enc.MaxBytes)
+
+	metadata := New()
+	assert.Nil(t, metadata.Metadata("nogps", nil))
+	enc, ok = metadata.Encoder("heif")
+	assert.True(t, ok)
+	assert.Equal(t, "heif", enc.Format)
+}
+
+func TestTransformsMetadata(t *testing.T) {
+	trans := New()
+	assert.False(t, trans.HasMetadataPolicy())
+	assert.NotNil(t, trans.Metadata("unknown", nil))
+	assert.NotNil(t, trans.Metadata("allowlist", nil))
+
+	assert.Nil(t, trans.Metadata("nogps", nil))
+	assert.True(t, trans.HasMetadataPolicy())
+	opts, ok := trans.MetadataOptions()
+	assert.True(t, ok)
+	assert.Equal(t, "nogps", opts.Policy)
+
+	allowlist := New()
+	assert.Nil(t, allowlist.Metadata("allowlist", []string{"Artist", "Copyright"}))
+	assert.NotEqual(t, allowlist.HashStr(), trans.HashStr())
+	opts, _ = allowlist.MetadataOptions()
+	assert.Equal(t, []string{"Artist", "Copyright"}, opts.Tags)
+
+	// strip and keep are handled by bimg
+	strip := New()
+	assert.Nil(t, strip.Metadata("strip", nil))
+	_, ok = strip.MetadataOptions()
+	assert.False(t, ok)
+	bOpts, err := strip.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.True(t, bOpts[0].StripMetadata)
+
+	merged := New()
+	merged.Resize(100, 0, false, false, false)
+	assert.Nil(t, merged.Merge(allowlist))
+	opts, ok = merged.MetadataOptions()
+	assert.True(t, ok)
+	assert.Equal(t, "allowlist", opts.Policy)
+}
+
+func TestTransformsPalette(t *testing.T) {
+	trans := New()
+	_, ok := trans.PaletteColors()
+	assert.False(t, ok)
+	assert.NotNil(t, trans.Palette(-1))
+	assert.NotNil(t, trans.Palette(maxPaletteColors+1))
+
+	assert.Nil(t, trans.Palette(0))
+	colors, ok := trans.PaletteColors()
+	assert.True(t, ok)
+	assert.Equal(t, defaultPaletteColors, colors)
+	assert.Contains(t, trans.Summary().Operations, "palette")
+	assert.True(t, trans.NoMerge)
+
+	other := New()
+	other.Palette(8)
+	assert.NotEqual(t, other.HashStr(), trans.HashStr())
+}
+
+func TestTransformsAutoQuality(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.AutoQuality("ultra"))
+	assert.Nil(t, trans.AutoQuality("eco"))
+
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.Equal(t, 65, opts[0].Quality)
+
+	trans.Format("webp")
+	opts, _ = trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Equal(t, 65, opts[0].Quality)
+
+	trans.Format("avif")
+	opts, _ = trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Equal(t, 45, opts[0].Quality)
+
+	hashStr := trans.HashStr()
+	trans.Quality(99)
+	opts, _ = trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Equal(t, 99, opts[0].Quality)
+	assert.NotEqual(t, hashStr, trans.HashStr())
+
+	trans = New()
+	trans.AutoQuality("")
+	opts, _ = trans.BimgOptions(ImageInfo{format: "png"})
+	assert.Equal(t, 0, opts[0].Quality)
+}
+
 func TestTransformsGrayscale(t *testing.T) {
 	trans := Transforms{}
 	trans.Grayscale()
@@ -258,6 +1000,68 @@ func TestTransforms_Watermark(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestTransforms_WatermarkPosition(t *testing.T) {
+	w := watermark{xPos: "center", yPos: "center"}
+	top, left := w.calculatePosition(600, 300, 100, 50)
+	assert.Equal(t, 100, top)
+	assert.Equal(t, 200, left)
+
+	w = watermark{xPos: "right", yPos: "bottom", scale: 0.2}
+	top, left = w.calculatePosition(600, 300, 100, 50)
+	assert.Equal(t, 200, top)
+	assert.Equal(t, 400, left)
+
+	w = watermark{xPos: "right", yPos: "top"}
+	top, left = w.calculatePosition(600, 300, 100, 50)
+	assert.Equal(t, 0, top)
+	assert.Equal(t, 400, left)
+
+	w = watermark{xPos: "left", yPos: "bottom"}
+	top, left = w.calculatePosition(600, 300, 100, 50)
+	assert.Equal(t, 200, top)
+	assert.Equal(t, 0, left)
+
+	w = watermark{xPos: "center", yPos: "bottom", margin: 10}
+	top, left = w.calculatePosition(500, 300, 100, 50)
+	assert.Equal(t, 240, top)
+	assert.Equal(t, 200, left)
+
+	w = watermark{xPos: "left", yPos: "center", margin: 10}
+	top, left = w.calculatePosition(50, 30, 100, 50)
+	assert.Equal(t, 0, top)
+	assert.Equal(t, 10, left)
+}
+
+func TestTransforms_WatermarkLayout(t *testing.T) {
+	trans := New()
+	assert.NotNil(t, trans.WatermarkLayout(0.2, 0, false))
+
+	trans.Watermark("image", "top-left", 0.5)
+	hashStr := trans.HashStr()
+	assert.NotNil(t, trans.WatermarkLayout(2, 0, false))
+	assert.NotNil(t, trans.WatermarkLayout(0.2, -1, false))
+	assert.Nil(t, trans.WatermarkLayout(0.2, 10, true))
+	assert.NotEqual(t, hashStr, trans.HashStr())
+	assert.Equal(t, watermark{image: "image", xPos: "left", yPos: "top", opacity: 0.5, scale: 0.2, margin: 10, tile: true}, trans.watermark)
+}
+
+func TestTransforms_WatermarkCache(t *testing.T) {
+	f, err := os.CreateTemp("", "watermark")
+	assert.Nil(t, err)
+	f.Write([]byte("watermark"))
+	f.Close()
+
+	w := watermark{image: f.Name()}
+	buf, err := w.fetchImage()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("watermark"), buf)
+
+	os.Remove(f.Name())
+	buf, err = w.fetchImage()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("watermark"), buf)
+}
+
 func TestTransforms_Merge_Resize(t *testing.T) {
 	tab := make([]Transforms, 2)
 	tab[0].Resize(100, 0, false, false, false)
@@ -604,3 +1408,62 @@ func TestResizeCropAutoValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestTransformsColorProfile(t *testing.T) {
+	trans := New()
+	_, ok, err := trans.ColorProfileOptions("srgb")
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	// cmyk images are converted to sRGB by default
+	profile, ok, err := trans.ColorProfileOptions("cmyk")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "srgb", profile.Target)
+
+	assert.NotNil(t, trans.ColorProfile("", true))
+	assert.NotNil(t, trans.ColorProfile("adobe", true))
+
+	assert.Nil(t, trans.ColorProfile("p3", true))
+	profile, ok, err = trans.ColorProfileOptions("cmyk")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "p3", profile.Target)
+
+	opts, err := trans.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.False(t, opts[0].NoProfile)
+
+	noProfile := New()
+	assert.Nil(t, noProfile.ColorProfile("p3", false))
+	assert.NotEqual(t, noProfile.HashStr(), trans.HashStr())
+	opts, err = noProfile.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.Nil(t, err)
+	assert.True(t, opts[0].NoProfile)
+
+	merged := New()
+	merged.Resize(100, 0, false, false, false)
+	assert.Nil(t, merged.Merge(noProfile))
+	profile, ok, _ = merged.ColorProfileOptions("srgb")
+	assert.True(t, ok)
+	assert.Equal(t, "p3", profile.Target)
+	opts, _ = merged.BimgOptions(ImageInfo{format: "jpeg"})
+	assert.True(t, opts[0].NoProfile)
+}
+
+func TestTransformsIntermediateFormat(t *testing.T) {
+	metadata := bimg.ImageMetadata{Type: "png"}
+	metadata.Size.Width = 100
+	metadata.Size.Height = 100
+
+	// source format is restored when engine replaced image with lossless one
+	trans := New()
+	trans.Resize(50, 0, false, false, false)
+	opts, err := trans.BimgOptions(NewImageInfo(metadata, "jpeg"))
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.JPEG, opts[0].Type)
+
+	opts, err = trans.BimgOptions(NewImageInfo(metadata, "png"))
+	assert.Nil(t, err)
+	assert.Equal(t, bimg.UNKNOWN, opts[0].Type)
+}
@@ -1,16 +1,22 @@
 package transforms
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"hash"
+	"image"
+	"image/draw"
+	"image/png"
 	"strconv"
 	"strings"
+	"time"
 
 	"math"
 
 	"github.com/aldor007/mort/pkg/helpers"
 	"github.com/h2non/bimg"
+	"github.com/karlseguin/ccache/v3"
 	"github.com/spaolacci/murmur3"
 )
 
@@ -26,6 +32,21 @@ var watermarkPosY = map[string]float32{
 	"bottom": 2. / 3.,
 }
 
+// textAlign maps watermark position to text alignment, it is used also for watermarks with margin
+var textAlign = map[string]float64{
+	"left":   0,
+	"top":    0,
+	"center": 0.5,
+	"right":  1,
+	"bottom": 1,
+}
+
+// WatermarkCacheTTL how long fetched watermark images are kept in memory
+var WatermarkCacheTTL = 10 * time.Minute
+
+// watermarkCache holds source images of watermarks and masks so they are not fetched for each transformation
+var watermarkCache = ccache.New(ccache.Configure[[]byte]().MaxSize(100))
+
 var cropGravity = map[string]bimg.Gravity{
 	"center": bimg.GravityCentre,
 	"north":  bimg.GravityNorth,
@@ -35,16 +56,294 @@ var cropGravity = map[string]bimg.Gravity{
 	"smart":  bimg.GravitySmart,
 }
 
+var namedColors = map[string]bimg.Color{
+	"white": {R: 255, G: 255, B: 255},
+	"black": {R: 0, G: 0, B: 0},
+	"red":   {R: 255, G: 0, B: 0},
+	"green": {R: 0, G: 128, B: 0},
+	"blue":  {R: 0, G: 0, B: 255},
+	"gray":  {R: 128, G: 128, B: 128},
+	"grey":  {R: 128, G: 128, B: 128},
+}
+
 type blur struct {
 	sigma   float64
 	minAmpl float64
 }
 
+// focalPoint holds point of interest used when image is cropped
+// values from 0 to 1 are fractions of image size, bigger values are pixels
+type focalPoint struct {
+	x   float64
+	y   float64
+	set bool
+}
+
+type extend struct {
+	width  int
+	height int
+	color  bimg.Color
+	alpha  uint8
+	blur   float64
+}
+
+type mask struct {
+	shape  string
+	radius int    // radius of corners in px for rounded shape
+	image  string // url of mask image for image shape
+}
+
+type metadataPolicy struct {
+	policy string
+	tags   []string // EXIF tags kept by allowlist policy
+}
+
+type sharpen struct {
+	sigma  float64
+	flat   float64
+	jagged float64
+}
+
+type modulate struct {
+	brightness float64
+	contrast   float64
+	saturation float64
+	hue        float64
+	set        bool
+}
+
+type tint struct {
+	color  bimg.Color
+	shadow bimg.Color
+	set    bool
+}
+
+type text struct {
+	text    string
+	font    string
+	size    int
+	dpi     int
+	color   bimg.Color
+	xPos    string
+	yPos    string
+	opacity float32
+}
+
 type watermark struct {
 	image   string
 	opacity float32
 	xPos    string
 	yPos    string
+	scale   float64 // width of watermark relative to output width, 0 keeps original size
+	margin  int     // distance from image edges or between tiles in px
+	tile    bool    // repeat watermark on whole image
+}
+
+// JXL is image type used for JPEG XL output. bimg doesn't know that format so
+// image is encoded by engine
+const JXL bimg.ImageType = 100
+
+// BlurHash and LQIP are placeholder outputs, instead of image engine returns text computed from downscaled image
+const (
+	BlurHash bimg.ImageType = 101
+	LQIP     bimg.ImageType = 102
+)
+
+// defaultAvifSpeed is libvips default for AVIF encoder speed
+const defaultAvifSpeed = 5
+
+// autoQualityLevels list of levels for automatic quality, index is used in hash
+var autoQualityLevels = []string{"good", "eco", "best"}
+
+// autoQuality quality used for given level and output format
+// formats that are not listed use encoder default quality
+var autoQuality = map[string]map[string]int{
+	"good": {"jpeg": 80, "webp": 80, "avif": 60, "heif": 60, "jxl": 75},
+	"eco":  {"jpeg": 65, "webp": 65, "avif": 45, "heif": 45, "jxl": 60},
+	"best": {"jpeg": 90, "webp": 90, "avif": 75, "heif": 75, "jxl": 90},
+}
+
+type encoder struct {
+	speed    int
+	speedSet bool
+	effort   int
+	lossless bool
+
+	progressive bool
+	subsample   string
+	trellis     bool
+	quantTable  int
+
+	palette bool
+	colors  int
+
+	nearLossless   bool
+	webpEffort     int
+	smartSubsample bool
+
+	maxBytes  int
+	downscale bool
+}
+
+// budgetFormats output formats for which engine can search for image fitting in max bytes
+var budgetFormats = map[string]bool{
+	"jpeg": true,
+	"webp": true,
+	"avif": true,
+	"png":  true,
+}
+
+// engineFormats output formats which can be encoded by engine
+var engineFormats = map[string]bool{
+	"jpeg": true,
+	"png":  true,
+	"webp": true,
+	"avif": true,
+	"heif": true,
+	"gif":  true,
+}
+
+// jpegSubsampleModes chroma subsampling modes of JPEG encoder
+var jpegSubsampleModes = map[string]bool{
+	"auto": true,
+	"on":   true,
+	"off":  true,
+}
+
+// default values for text overlay
+const (
+	defaultTextFont = "sans"
+	defaultTextSize = 24
+	defaultTextDPI  = 72
+)
+
+// defaultExtendBlur sigma of gaussian blur used for background in blur extend mode
+const defaultExtendBlur = 20
+
+// alphaFormats output formats which can hold transparency
+var alphaFormats = map[bimg.ImageType]bool{
+	bimg.PNG:  true,
+	bimg.WEBP: true,
+	bimg.AVIF: true,
+	JXL:       true,
+}
+
+// maxDPI maximal resolution of rasterized documents
+const maxDPI = 600
+
+// default and maximal number of colors in palette
+const (
+	defaultPaletteColors = 5
+	maxPaletteColors     = 16
+)
+
+// DefaultMaxFrames maximal number of frames of animated image which are processed when limit is not configured
+const DefaultMaxFrames = 100
+
+// DefaultMaxRasterArea maximal width * height of rasterized document page or extracted frame when limit is not configured
+const DefaultMaxRasterArea = 100000000
+
+// animatedFormats formats in which animation can be saved
+var animatedFormats = map[string]bool{"gif": true, "webp": true}
+
+// animatedOperations operations which can be applied on each frame of animation
+var animatedOperations = map[string]bool{"resize": true, "crop": true}
+
+// Animation describes processing of all frames of animated image by engine
+type Animation struct {
+	Width     int
+	Height    int
+	Crop      bool // crop frames from center to given size
+	Enlarge   bool
+	Force     bool // ignore aspect ratio
+	MaxFrames int
+	Format    string // output format of image, gif or webp
+	Quality   int    // output quality, 0 means encoder default
+}
+
+// defaultTrimThreshold difference from background color below which pixels are trimmed
+const defaultTrimThreshold = 10
+
+// maxZoom maximal integer zoom factor
+const maxZoom = 10
+
+// defaultSharpenJagged libvips default of sharpening applied to jagged areas
+const defaultSharpenJagged = 3
+
+// Adjustments describes tonal operations which are applied by engine after bimg operations
+type Adjustments struct {
+	SharpenSigma  float64 // 0 means no sharpening
+	SharpenFlat   float64 // sharpening of flat areas
+	SharpenJagged float64 // sharpening of jagged areas
+	Modulate      bool
+	Brightness    float64 // multiplier of lightness
+	Contrast      float64 // multiplier of distance from middle gray
+	Saturation    float64 // multiplier of chroma
+	Hue           float64 // rotation of hue in degrees
+	Tint          bool
+	TintColor     bimg.Color // color of highlights
+	TintShadow    bimg.Color // color of shadows
+}
+
+// ExtendOptions describes canvas on which image is placed by engine
+type ExtendOptions struct {
+	Width  int
+	Height int
+	Color  bimg.Color // background color
+	Alpha  uint8      // background opacity, 0 is transparent
+	Blur   float64    // when set, blurred copy of image is used as background
+}
+
+// MaskOptions describes mask which is applied on image by engine, transparent parts of mask are removed from image
+type MaskOptions struct {
+	Shape  string // circle, rounded or image
+	Radius int    // radius of corners in px for rounded shape
+	Image  []byte // mask image, its alpha channel or luminance is used
+}
+
+// MetadataOptions describes which metadata of image are kept by engine
+type MetadataOptions struct {
+	Policy string   // icc, nogps or allowlist
+	Tags   []string // EXIF tags kept by allowlist policy, "xmp" and "iptc" keep whole packets
+}
+
+// ColorProfileOptions describes ICC profile conversion which is done by engine before other operations
+type ColorProfileOptions struct {
+	Target  string // built-in profile: srgb or p3
+	Profile []byte // ICC profile used instead of built-in one
+}
+
+// TextOverlay describes text which is drawn on image by engine
+type TextOverlay struct {
+	Text    string
+	Font    string     // pango font description e.x. "sans 24"
+	DPI     int        // resolution used for rendering text
+	Color   bimg.Color // text color
+	XAlign  float64    // horizontal position 0 (left) - 1 (right)
+	YAlign  float64    // vertical position 0 (top) - 1 (bottom)
+	Opacity float64
+}
+
+// Encoder describes output options for formats encoded outside of bimg
+// jpeg, png and webp are encoded by engine only when options which bimg doesn't support are used
+type Encoder struct {
+	Format         string
+	Quality        int
+	Effort         int // JPEG XL or WebP encoder effort
+	Lossless       bool
+	StripMetadata  bool
+	Interlace      bool
+	Subsample      string // JPEG chroma subsampling mode: auto, on or off
+	Trellis        bool
+	QuantTable     int
+	Palette        bool
+	Compression    int
+	Colors         int // max number of colors of PNG palette
+	NearLossless   bool
+	SmartSubsample bool
+	Speed          int // AVIF encoder speed
+	MaxBytes       int // max size of output, quality is lowered until image fits in it
+	Downscale      bool
 }
 
 var angleMap = map[int]bimg.Angle{
@@ -55,27 +354,128 @@ var angleMap = map[int]bimg.Angle{
 }
 var prime64 = 1099511628211
 
+// fetchImage returns image from given url, images are cached so they are not fetched for each transformation
+func fetchImage(uri string) ([]byte, error) {
+	item, err := watermarkCache.Fetch(uri, WatermarkCacheTTL, func() ([]byte, error) {
+		return helpers.FetchObject(uri)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item.Value(), nil
+}
+
 func (w watermark) fetchImage() ([]byte, error) {
-	return helpers.FetchObject(w.image)
+	return fetchImage(w.image)
 }
 
 func (w watermark) calculatePostion(width, height int) (top int, left int) {
 	top = int(watermarkPosY[w.yPos] * float32(height))
-	left = int(watermarkPosX[w.yPos] * float32(width))
+	left = int(watermarkPosX[w.xPos] * float32(width))
+	return
+}
+
+// calculatePosition returns position of watermark with given size on image
+// watermark with margin is aligned to image edges, without it position is the same as in calculatePostion
+func (w watermark) calculatePosition(width, height, wmWidth, wmHeight int) (top int, left int) {
+	if w.margin == 0 {
+		return w.calculatePostion(width, height)
+	}
+
+	top = w.margin + int(textAlign[w.yPos]*float64(height-wmHeight-2*w.margin))
+	left = w.margin + int(textAlign[w.xPos]*float64(width-wmWidth-2*w.margin))
+	if top < 0 {
+		top = 0
+	}
+
+	if left < 0 {
+		left = 0
+	}
+
 	return
 }
 
+// prepareImage returns watermark image prepared for output image of given size and its position
+func (w watermark) prepareImage(width, height int) ([]byte, int, int, error) {
+	buf, err := w.fetchImage()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if w.scale > 0 {
+		buf, err = bimg.Resize(buf, bimg.Options{Width: int(math.Max(1, math.Round(w.scale*float64(width)))), Enlarge: true, Type: bimg.PNG})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	if w.tile {
+		buf, err = tileImage(buf, width, height, w.margin)
+		return buf, 0, 0, err
+	}
+
+	size, err := bimg.NewImage(buf).Size()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	top, left := w.calculatePosition(width, height, size.Width, size.Height)
+	return buf, top, left, nil
+}
+
+// tileImage repeats image on canvas of given size, tiles are separated by gap px
+func tileImage(buf []byte, width, height, gap int) ([]byte, error) {
+	pngBuf, err := bimg.NewImage(buf).Convert(bimg.PNG)
+	if err != nil {
+		return nil, err
+	}
+
+	tile, err := png.Decode(bytes.NewReader(pngBuf))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := tile.Bounds()
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y += bounds.Dy() + gap {
+		for x := 0; x < width; x += bounds.Dx() + gap {
+			draw.Draw(canvas, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), tile, bounds.Min, draw.Src)
+		}
+	}
+
+	out := bytes.Buffer{}
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err = encoder.Encode(&out, canvas); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
 // ImageInfo holds information about image
 type ImageInfo struct {
 	width       int    // width of image in px
 	height      int    // height of image in px
 	format      string // format of image in string e.x. "jpg"
 	orientation int
+	// intermediate is true when engine replaced source image with lossless one, so source format has to be restored
+	intermediate bool
 }
 
 // NewImageInfo create new ImageInfo object from bimg metadata
 func NewImageInfo(metadata bimg.ImageMetadata, format string) ImageInfo {
-	return ImageInfo{width: metadata.Size.Width, height: metadata.Size.Height, format: format, orientation: metadata.Orientation}
+	return ImageInfo{width: metadata.Size.Width, height: metadata.Size.Height, format: format, orientation: metadata.Orientation,
+		intermediate: metadata.Type != "" && metadata.Type != format}
+}
+
+// Summary describe result of transforms used for checking bucket limits
+type Summary struct {
+	Width      int      // output width, 0 if not changed
+	Height     int      // output height, 0 if not changed
+	Quality    int      // output quality, 0 if not changed
+	DPI        int      // highest resolution of rasterized document or text, 0 if not changed
+	Operations []string // names of operations as used in query "operation" parameter
 }
 
 // Transforms struct hold information about what operations should be performed on image
@@ -85,6 +485,8 @@ type Transforms struct {
 	areaHeight          int
 	areaWidth           int
 	quality             int
+	autoQuality         string
+	autoFormat          bool
 	compression         int
 	zoom                int
 	top                 int
@@ -98,18 +500,39 @@ type Transforms struct {
 	force               bool
 	noAutoRotate        bool
 	noProfile           bool
+	colorProfile        string
 	interlace           bool
 	stripMetadata       bool
 	trim                bool
+	trimThreshold       float64
+	trimBackground      bimg.Color
+	frame               int
+	frameSet            bool
+	dpi                 int
+	info                bool
+	paletteColors       int
+	maxFrames           int
+	maxRasterArea       int
 	preserveAspectRatio bool
 	rotate              bimg.Angle
 	interpretation      bimg.Interpretation
 	gravity             bimg.Gravity
+	background          *bimg.Color
+	focal               focalPoint
 	blur                blur
+	sharpen             sharpen
+	modulate            modulate
+	gamma               float64
+	tint                tint
 	format              bimg.ImageType
 	FormatStr           string
 
 	watermark watermark
+	text      text
+	extend    extend
+	mask      mask
+	metadata  metadataPolicy
+	encoder   encoder
 
 	NotEmpty bool
 	NoMerge  bool
@@ -132,6 +555,8 @@ func (t *Transforms) ToJSON() map[string]interface{} {
 		"areaHeight":          t.areaHeight,
 		"areaWidth":           t.areaWidth,
 		"quality":             t.quality,
+		"autoQuality":         t.autoQuality,
+		"autoFormat":          t.autoFormat,
 		"compression":         t.compression,
 		"zoom":                t.zoom,
 		"top":                 t.top,
@@ -145,15 +570,43 @@ func (t *Transforms) ToJSON() map[string]interface{} {
 		"force":               t.force,
 		"noAutoRotate":        t.noAutoRotate,
 		"noProfile":           t.noProfile,
+		"colorProfile":        t.colorProfile,
 		"interlace":           t.interlace,
 		"stripMetada":         t.stripMetadata,
 		"trim":                t.trim,
+		"frame":               t.frame,
+		"dpi":                 t.dpi,
+		"info":                t.info,
+		"paletteColors":       t.paletteColors,
 		"preserveAspectRatio": t.preserveAspectRatio,
 		"rotate":              t.rotate,
 		"interpretation":      t.interpretation,
 		"gravity":             t.gravity,
+		"background":          t.background,
+		"focalX":              t.focal.x,
+		"focalY":              t.focal.y,
 		"blur":                t.blur,
+		"sharpen":             t.sharpen.sigma,
+		"gamma":               t.gamma,
 		"format":              t.format,
+		"speed":               t.encoder.speed,
+		"effort":              t.encoder.effort,
+		"lossless":            t.encoder.lossless,
+		"progressive":         t.encoder.progressive,
+		"subsample":           t.encoder.subsample,
+		"trellis":             t.encoder.trellis,
+		"quantTable":          t.encoder.quantTable,
+		"palette":             t.encoder.palette,
+		"colors":              t.encoder.colors,
+		"nearLossless":        t.encoder.nearLossless,
+		"webpEffort":          t.encoder.webpEffort,
+		"smartSubsample":      t.encoder.smartSubsample,
+		"maxBytes":            t.encoder.maxBytes,
+		"downscale":           t.encoder.downscale,
+		"extendWidth":         t.extend.width,
+		"extendHeight":        t.extend.height,
+		"mask":                t.mask.shape,
+		"metadata":            t.metadata.policy,
 		"autoCropWidth":       t.autoCropWidth,
 		"autoCropHeight":      t.autoCropHeight,
 		"hash":                t.HashStr(),
@@ -190,6 +643,134 @@ func (t *Transforms) Resize(width, height int, enlarge bool, preserveAspectRatio
 	return nil
 }
 
+// Dimensions returns target width and height of resize or crop operation
+func (t *Transforms) Dimensions() (width, height int) {
+	return t.width, t.height
+}
+
+// SetDimensions change target width and height of already defined resize or crop operation
+func (t *Transforms) SetDimensions(width, height int) error {
+	if width < 0 || height < 0 {
+		return errors.New("width and height cannot be negative")
+	}
+
+	if t.width == 0 && t.height == 0 {
+		return errors.New("no resize or crop operation to change")
+	}
+
+	t.width = width
+	t.height = height
+	t.transHash.write(1131, uint64(width)*7, uint64(height)*3)
+	return nil
+}
+
+// Summary returns output dimensions, quality and list of operations
+func (t *Transforms) Summary() Summary {
+	s := Summary{Width: t.width, Height: t.height, Quality: t.limitQuality(), DPI: t.dpi}
+	switch {
+	case t.crop:
+		s.Operations = append(s.Operations, "crop")
+	case t.width != 0 || t.height != 0:
+		s.Operations = append(s.Operations, "resize")
+	}
+
+	if t.autoCropWidth != 0 || t.autoCropHeight != 0 {
+		s.Operations = append(s.Operations, "resizeCropAuto")
+		if s.Width == 0 && s.Height == 0 {
+			s.Width, s.Height = t.autoCropWidth, t.autoCropHeight
+		}
+	}
+
+	if t.areaWidth != 0 || t.areaHeight != 0 {
+		s.Operations = append(s.Operations, "extract")
+		if s.Width == 0 && s.Height == 0 {
+			s.Width, s.Height = t.areaWidth, t.areaHeight
+		}
+	}
+
+	if t.mask.shape != "" {
+		s.Operations = append(s.Operations, "mask")
+	}
+
+	if t.extend.width != 0 {
+		s.Operations = append(s.Operations, "extend")
+		if s.Width == 0 && s.Height == 0 {
+			s.Width, s.Height = t.extend.width, t.extend.height
+		}
+	}
+
+	if t.watermark.image != "" {
+		s.Operations = append(s.Operations, "watermark")
+	}
+
+	if t.text.text != "" {
+		s.Operations = append(s.Operations, "text")
+		if t.text.dpi > s.DPI {
+			s.DPI = t.text.dpi
+		}
+	}
+
+	if t.blur.sigma != 0 {
+		s.Operations = append(s.Operations, "blur")
+	}
+
+	if t.sharpen.sigma != 0 {
+		s.Operations = append(s.Operations, "sharpen")
+	}
+
+	if t.modulate.set {
+		s.Operations = append(s.Operations, "modulate")
+	}
+
+	if t.gamma != 0 {
+		s.Operations = append(s.Operations, "gamma")
+	}
+
+	if t.tint.set {
+		s.Operations = append(s.Operations, "tint")
+	}
+
+	if t.rotate != 0 {
+		s.Operations = append(s.Operations, "rotate")
+	}
+
+	if t.interpretation == bimg.InterpretationBW {
+		s.Operations = append(s.Operations, "grayscale")
+	}
+
+	if t.flip {
+		s.Operations = append(s.Operations, "flip")
+	}
+
+	if t.flop {
+		s.Operations = append(s.Operations, "flop")
+	}
+
+	if t.trim {
+		s.Operations = append(s.Operations, "trim")
+	}
+
+	if t.frameSet {
+		s.Operations = append(s.Operations, "frame")
+	}
+
+	if t.info {
+		s.Operations = append(s.Operations, "info")
+	}
+
+	if t.paletteColors != 0 {
+		s.Operations = append(s.Operations, "palette")
+	}
+
+	if t.zoom > 1 {
+		s.Operations = append(s.Operations, "zoom")
+		s.Width *= t.zoom
+		s.Height *= t.zoom
+	}
+
+	return s
+}
+
 // Extract area from image with given properties
 func (t *Transforms) Extract(top, left, width, height int) error {
 	// Validate coordinates are non-negative
@@ -235,133 +816,1035 @@ func (t *Transforms) Crop(width, height int, gravity string, enlarge, embed bool
 		t.transHash.write(3333)
 	}
 
-	if t.enlarge {
-		t.transHash.write(54324)
+	if t.enlarge {
+		t.transHash.write(54324)
+	}
+
+	return nil
+}
+
+// Gravity change gravity used when image is cropped
+func (t *Transforms) Gravity(gravity string) error {
+	g, ok := cropGravity[gravity]
+	if !ok {
+		return errors.New("unknown gravity " + gravity)
+	}
+
+	t.gravity = g
+	t.NotEmpty = true
+	t.transHash.write(1213, uint64(g))
+	return nil
+}
+
+// Background set color used for embedding and flattening transparent images
+// color can be given as hex ("#ff0000", "f00") or name ("white")
+func (t *Transforms) Background(color string) error {
+	c, err := parseColor(color)
+	if err != nil {
+		return err
+	}
+
+	t.background = &c
+	t.NotEmpty = true
+	t.transHash.write(1214, uint64(c.R), uint64(c.G), uint64(c.B))
+	return nil
+}
+
+// Extend place image on canvas of given size, image is downsized to fit in it
+// mode "color" fills rest of canvas with background color ("transparent" and "#rrggbbaa" are allowed), mode "blur" uses blurred copy of image
+func (t *Transforms) Extend(width, height int, background string, mode string) error {
+	if width <= 0 || height <= 0 {
+		return errors.New("extend width and height must be positive")
+	}
+
+	e := extend{width: width, height: height}
+	switch mode {
+	case "", "color":
+		if background == "" {
+			background = "white"
+		}
+
+		c, alpha, err := parseColorAlpha(background)
+		if err != nil {
+			return err
+		}
+		e.color = c
+		e.alpha = alpha
+	case "blur":
+		e.blur = defaultExtendBlur
+	default:
+		return errors.New("unknown extend mode " + mode)
+	}
+
+	t.extend = e
+	t.NotEmpty = true
+	t.transHash.write(1217, uint64(width)*7, uint64(height), uint64(e.color.R), uint64(e.color.G), uint64(e.color.B), uint64(e.alpha), math.Float64bits(e.blur))
+	return nil
+}
+
+// ExtendOptions returns canvas on which image should be placed
+func (t *Transforms) ExtendOptions() (ExtendOptions, bool) {
+	if t.extend.width == 0 {
+		return ExtendOptions{}, false
+	}
+
+	return ExtendOptions{
+		Width:  t.extend.width,
+		Height: t.extend.height,
+		Color:  t.extend.color,
+		Alpha:  t.extend.alpha,
+		Blur:   t.extend.blur,
+	}, true
+}
+
+// FocalPoint set point which should be kept in center of crop window for crop and resizeCropAuto
+// x and y can be given as fractions of image size (0..1) or as pixel coordinates
+func (t *Transforms) FocalPoint(x, y float64) error {
+	if x < 0 || y < 0 || math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return errors.New("invalid focal point")
+	}
+
+	t.focal = focalPoint{x: x, y: y, set: true}
+	t.NotEmpty = true
+	t.transHash.write(1215, math.Float64bits(x), math.Float64bits(y))
+	return nil
+}
+
+// Crop extract part of image
+func (t *Transforms) ResizeCropAuto(width, height int) error {
+	// Validate width and height are non-negative
+	if width < 0 || height < 0 {
+		return errors.New("width and height cannot be negative")
+	}
+
+	t.NotEmpty = true
+	t.autoCropWidth = width
+	t.autoCropHeight = height
+	t.NoMerge = true
+
+	t.transHash.write(31229, uint64(width)*2, uint64(height))
+	return nil
+}
+
+// Interlace enable image interlace
+func (t *Transforms) Interlace() error {
+	t.interlace = true
+	t.NotEmpty = true
+	t.transHash.write(1311, 71)
+	return nil
+}
+
+// Quality change image quality
+func (t *Transforms) Quality(quality int) error {
+	// Validate quality is in range [1, 100]
+	if quality < 1 || quality > 100 {
+		return errors.New("quality must be between 1 and 100")
+	}
+	t.quality = quality
+	t.NotEmpty = true
+	t.transHash.write(1401, uint64(t.quality))
+	return nil
+}
+
+// AutoQuality choose quality depending on output format, level is one of "good", "eco", "best"
+// quality set explicitly has precedence
+func (t *Transforms) AutoQuality(level string) error {
+	if level == "" {
+		level = autoQualityLevels[0]
+	}
+
+	for i, l := range autoQualityLevels {
+		if l == level {
+			t.autoQuality = level
+			t.NotEmpty = true
+			t.transHash.write(1402, uint64(i))
+			return nil
+		}
+	}
+
+	return errors.New("unknown auto quality level " + level)
+}
+
+// AutoFormat mark that output format should be negotiated with client
+func (t *Transforms) AutoFormat() {
+	t.autoFormat = true
+	t.NotEmpty = true
+	t.transHash.write(1412)
+}
+
+// HasAutoFormat returns true when output format should be negotiated with client
+func (t *Transforms) HasAutoFormat() bool {
+	return t.autoFormat
+}
+
+// outputQuality returns quality for given output format
+func (t *Transforms) outputQuality(format string) int {
+	if t.quality != 0 || t.autoQuality == "" {
+		return t.quality
+	}
+
+	if format == "jpg" {
+		format = "jpeg"
+	}
+
+	return autoQuality[t.autoQuality][format]
+}
+
+// limitQuality returns quality checked against bucket limits
+// automatic quality is resolved for output format, when format is not known yet (e.g. it will be negotiated)
+// the highest quality of the level is used
+func (t *Transforms) limitQuality() int {
+	if t.quality != 0 || t.autoQuality == "" {
+		return t.quality
+	}
+
+	if t.FormatStr != "" {
+		return t.outputQuality(t.FormatStr)
+	}
+
+	quality := 0
+	for _, q := range autoQuality[t.autoQuality] {
+		quality = max(quality, q)
+	}
+
+	return quality
+}
+
+// StripMetadata remove EXIF from image
+func (t *Transforms) StripMetadata() error {
+	t.stripMetadata = true
+	t.NotEmpty = true
+	t.transHash.write(1999)
+	return nil
+}
+
+// Metadata set which metadata of image are kept in output
+// policy can be "keep" (default), "strip" (remove all), "icc" (keep only ICC profile), "nogps" (remove GPS EXIF tags)
+// or "allowlist" (keep only given EXIF tags, "xmp" and "iptc" tags keep whole packets)
+func (t *Transforms) Metadata(policy string, tags []string) error {
+	switch policy {
+	case "keep", "icc", "nogps":
+	case "strip":
+		t.stripMetadata = true
+	case "allowlist":
+		if len(tags) == 0 {
+			return errors.New("allowlist metadata policy requires tags")
+		}
+	default:
+		return errors.New("unknown metadata policy " + policy)
+	}
+
+	t.metadata = metadataPolicy{policy: policy, tags: tags}
+	t.NotEmpty = true
+	t.transHash.write(1230, murmur3.Sum64([]byte(policy)), murmur3.Sum64([]byte(strings.Join(tags, ","))))
+	return nil
+}
+
+// HasMetadataPolicy returns true when metadata policy was set
+func (t *Transforms) HasMetadataPolicy() bool {
+	return t.metadata.policy != ""
+}
+
+// MetadataOptions returns metadata policy which has to be applied by engine on image
+// keep and strip policies are handled by bimg
+func (t *Transforms) MetadataOptions() (MetadataOptions, bool) {
+	switch t.metadata.policy {
+	case "icc", "nogps", "allowlist":
+	default:
+		return MetadataOptions{}, false
+	}
+
+	return MetadataOptions{
+		Policy: t.metadata.policy,
+		Tags:   t.metadata.tags,
+	}, true
+}
+
+// builtinProfiles ICC profiles which are provided by libvips
+var builtinProfiles = map[string]bool{
+	"srgb": true,
+	"p3":   true,
+}
+
+// ColorProfile convert colors of image from its embedded ICC profile to target profile
+// target can be "srgb", "p3" or url of .icc file (http or mort://), empty target doesn't change colors
+// when embed is false ICC profile is removed from output image
+func (t *Transforms) ColorProfile(target string, embed bool) error {
+	if target == "" && embed {
+		return errors.New("missing color profile target")
+	}
+
+	if target != "" && !builtinProfiles[target] && !strings.Contains(target, "://") {
+		return errors.New("unknown color profile " + target)
+	}
+
+	t.colorProfile = target
+	t.noProfile = !embed
+	t.NotEmpty = true
+	t.transHash.write(1231, murmur3.Sum64([]byte(target)))
+	if !embed {
+		t.transHash.write(1232)
+	}
+	return nil
+}
+
+// ColorProfileOptions returns ICC conversion which should be done on image with given interpretation
+// CMYK images are always converted, by default to sRGB, because other operations work only on RGB
+func (t *Transforms) ColorProfileOptions(space string) (ColorProfileOptions, bool, error) {
+	target := t.colorProfile
+	if target == "" {
+		if space != "cmyk" {
+			return ColorProfileOptions{}, false, nil
+		}
+		target = "srgb"
+	}
+
+	if builtinProfiles[target] {
+		return ColorProfileOptions{Target: target}, true, nil
+	}
+
+	buf, err := fetchImage(target)
+	if err != nil {
+		return ColorProfileOptions{}, true, err
+	}
+
+	return ColorProfileOptions{Profile: buf}, true, nil
+}
+
+// Blur blur whole image
+func (t *Transforms) Blur(sigma, minAmpl float64) error {
+	// Validate sigma is positive and not too large
+	if sigma <= 0 {
+		return errors.New("sigma must be positive")
+	}
+	if sigma > 100 {
+		return errors.New("sigma value too large, maximum allowed is 100")
+	}
+	t.NotEmpty = true
+	t.blur.sigma = sigma
+	t.blur.minAmpl = minAmpl
+	t.transHash.write(19121, uint64(t.blur.sigma*1000), uint64(t.blur.minAmpl*1000))
+	return nil
+}
+
+// Hash return unique transform identifier
+func (t *Transforms) Hash() hash.Hash64 {
+	hashValue := murmur3.New64WithSeed(20171108)
+	transHashB := make([]byte, 8)
+	binary.LittleEndian.PutUint64(transHashB, t.transHash.value())
+	hashValue.Write(transHashB)
+	return hashValue
+}
+
+func (t *Transforms) HashStr() string {
+	return strconv.FormatUint(uint64(t.Hash().Sum64()), 16)
+}
+
+// Format change image format
+func (t *Transforms) Format(format string) error {
+	t.NotEmpty = true
+	f, err := imageFormat(format)
+	if err != nil {
+		return err
+	}
+	t.format = f
+	t.FormatStr = format
+	t.transHash.write(1122121, uint64(f))
+	return nil
+}
+
+// Speed set AVIF encoder CPU effort (0 - slowest, 8 - fastest)
+func (t *Transforms) Speed(speed int) error {
+	if speed < 0 || speed > 8 {
+		return errors.New("speed must be between 0 and 8")
+	}
+	t.encoder.speed = speed
+	t.encoder.speedSet = true
+	t.NotEmpty = true
+	t.transHash.write(1122131, uint64(speed))
+	return nil
+}
+
+// Effort set JPEG XL encoder effort (1 - fastest, 9 - slowest)
+func (t *Transforms) Effort(effort int) error {
+	if effort < 1 || effort > 9 {
+		return errors.New("effort must be between 1 and 9")
+	}
+	t.encoder.effort = effort
+	t.NotEmpty = true
+	t.transHash.write(1122141, uint64(effort))
+	return nil
+}
+
+// Lossless enable lossless compression for formats that support it (webp, avif, jxl)
+func (t *Transforms) Lossless() error {
+	t.encoder.lossless = true
+	t.NotEmpty = true
+	t.transHash.write(1122151)
+	return nil
+}
+
+// JpegOptions set JPEG encoder options
+// subsample can be "auto", "on" (4:2:0) or "off" (4:4:4), trellis and quantTable (0 - 8) require libvips with mozjpeg
+// empty subsample and zero quantTable use encoder defaults
+func (t *Transforms) JpegOptions(progressive bool, subsample string, trellis bool, quantTable int) error {
+	if subsample != "" && !jpegSubsampleModes[subsample] {
+		return errors.New("unknown subsample mode " + subsample)
+	}
+	if quantTable < 0 || quantTable > 8 {
+		return errors.New("quantTable must be between 0 and 8")
+	}
+
+	t.encoder.progressive = progressive
+	t.encoder.subsample = subsample
+	t.encoder.trellis = trellis
+	t.encoder.quantTable = quantTable
+	t.NotEmpty = true
+	t.transHash.write(1233, boolToUint64(progressive), murmur3.Sum64([]byte(subsample)), boolToUint64(trellis), uint64(quantTable))
+	return nil
+}
+
+// pngPaletteColors numbers of palette colors which PNG encoder can save, they match palette bit depth 1, 2, 4 and 8
+var pngPaletteColors = map[int]bool{2: true, 4: true, 16: true, 256: true}
+
+// PngOptions set PNG encoder options
+// compression 1 (fastest) - 9 (smallest) and colors 2, 4, 16 or 256 of palette, zero values use encoder defaults
+// setting colors enables palette
+func (t *Transforms) PngOptions(palette bool, compression int, colors int) error {
+	if compression != 0 && (compression < 1 || compression > 9) {
+		return errors.New("compression must be between 1 and 9 or 0 for encoder default")
+	}
+	if colors != 0 && !pngPaletteColors[colors] {
+		return errors.New("colors must be 2, 4, 16 or 256")
+	}
+
+	t.encoder.palette = palette || colors != 0
+	t.encoder.colors = colors
+	t.compression = compression
+	t.NotEmpty = true
+	t.transHash.write(1234, boolToUint64(t.encoder.palette), uint64(compression), uint64(colors))
+	return nil
+}
+
+// WebpOptions set WebP encoder options, effort 1 (fastest) - 6 (slowest), zero uses encoder default
+// lossless compression is enabled by Lossless
+func (t *Transforms) WebpOptions(nearLossless bool, effort int, smartSubsample bool) error {
+	if effort != 0 && (effort < 1 || effort > 6) {
+		return errors.New("effort must be between 1 and 6 or 0 for encoder default")
+	}
+
+	t.encoder.nearLossless = nearLossless
+	t.encoder.webpEffort = effort
+	t.encoder.smartSubsample = smartSubsample
+	t.NotEmpty = true
+	t.transHash.write(1235, boolToUint64(nearLossless), uint64(effort), boolToUint64(smartSubsample))
+	return nil
+}
+
+// MaxBytes limit size of output image, engine searches for highest quality for which image fits in limit
+// when downscale is true image is also made smaller if it doesn't fit with lowest quality
+func (t *Transforms) MaxBytes(maxBytes int, downscale bool) error {
+	if maxBytes <= 0 {
+		return errors.New("maxBytes must be positive")
+	}
+
+	t.encoder.maxBytes = maxBytes
+	t.encoder.downscale = downscale
+	t.NotEmpty = true
+	t.transHash.write(1236, uint64(maxBytes))
+	if downscale {
+		t.transHash.write(1237)
+	}
+	return nil
+}
+
+// outputFormat returns name of format in which image of given format is saved
+func (t *Transforms) outputFormat(source string) string {
+	format := source
+	if t.FormatStr != "" {
+		format = t.FormatStr
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+
+	// masked image needs alpha channel, formats without it are replaced by png
+	if t.mask.shape != "" {
+		if f, err := imageFormat(format); err != nil || !alphaFormats[f] {
+			return "png"
+		}
+	}
+
+	return format
+}
+
+// postProcessed returns true when image is changed by engine after bimg operations
+func (t *Transforms) postProcessed() bool {
+	_, metadata := t.MetadataOptions()
+	return t.sharpen.sigma != 0 || t.modulate.set || t.tint.set || t.text.text != "" || t.mask.shape != "" || metadata
+}
+
+// customEncoder returns true when image in given output format has to be encoded by engine
+// it is needed for options which are not supported by bimg
+func (t *Transforms) customEncoder(format string) bool {
+	// engine operations get lossless image, so output is encoded only once after them
+	if t.postProcessed() && engineFormats[format] {
+		return true
+	}
+
+	// quality of image fitting in max bytes is chosen by engine
+	if t.encoder.maxBytes != 0 && budgetFormats[format] {
+		return true
+	}
+
+	switch format {
+	case "jpeg":
+		return t.encoder.subsample != "" || t.encoder.trellis || t.encoder.quantTable != 0
+	case "png":
+		return t.encoder.colors != 0
+	case "webp":
+		return t.encoder.nearLossless || t.encoder.webpEffort != 0 || t.encoder.smartSubsample
+	}
+
+	return false
+}
+
+// Encoder returns options for output that should be encoded by engine instead of bimg
+// format is format of image passed to transform, it is used when transform doesn't change format
+func (t *Transforms) Encoder(format string) (Encoder, bool) {
+	if t.format == JXL || t.format == BlurHash || t.format == LQIP {
+		enc := Encoder{
+			Format:        t.FormatStr,
+			Quality:       t.outputQuality(t.FormatStr),
+			Effort:        t.encoder.effort,
+			Lossless:      t.encoder.lossless,
+			StripMetadata: t.stripMetadata,
+		}
+		if t.format == JXL {
+			enc.MaxBytes = t.encoder.maxBytes
+			enc.Downscale = t.encoder.downscale
+		}
+		return enc, true
+	}
+
+	output := t.outputFormat(format)
+	if !t.customEncoder(output) {
+		return Encoder{}, false
+	}
+
+	enc := Encoder{
+		Format:         output,
+		Quality:        t.outputQuality(output),
+		Lossless:       t.encoder.lossless,
+		StripMetadata:  t.stripMetadata,
+		Interlace:      t.interlace || t.encoder.progressive,
+		Subsample:      t.encoder.subsample,
+		Trellis:        t.encoder.trellis,
+		QuantTable:     t.encoder.quantTable,
+		Palette:        t.encoder.palette,
+		Compression:    t.compression,
+		Colors:         t.encoder.colors,
+		NearLossless:   t.encoder.nearLossless,
+		SmartSubsample: t.encoder.smartSubsample,
+	}
+	if budgetFormats[output] {
+		enc.MaxBytes = t.encoder.maxBytes
+		enc.Downscale = t.encoder.downscale
+	}
+	switch output {
+	case "webp":
+		enc.Effort = t.encoder.webpEffort
+	case "avif":
+		enc.Speed = defaultAvifSpeed
+		if t.encoder.speedSet {
+			enc.Speed = t.encoder.speed
+		}
+	}
+
+	return enc, true
+}
+
+// Watermark merge two image in one
+func (t *Transforms) Watermark(image string, position string, opacity float32) error {
+	if image == "" || position == "" {
+		return errors.New("missing required params image or position")
+	}
+
+	// Validate opacity is in range [0, 1]
+	if opacity < 0 || opacity > 1 {
+		return errors.New("opacity must be between 0 and 1")
+	}
+
+	p := strings.Split(position, "-")
+	if len(p) != 2 {
+		return errors.New("invalid position given")
+	}
+
+	if _, ok := watermarkPosY[p[0]]; !ok {
+		return errors.New("invalid first position argument")
+	}
+
+	if _, ok := watermarkPosX[p[1]]; !ok {
+		return errors.New("invalid second position argument")
+	}
+
+	if image == "" {
+		return errors.New("empty image")
+	}
+
+	t.NotEmpty = true
+	t.transHash.write(171200, uint64(len(image)), uint64(len(position)), uint64(opacity*100))
+	t.watermark = watermark{image: image, xPos: p[1], yPos: p[0], opacity: opacity}
+	return nil
+}
+
+// WatermarkLayout change size and placement of watermark
+// scale is width of watermark relative to output width (0 keeps original size), margin is given in px,
+// when tile is set watermark is repeated on whole image and margin is used as gap between tiles
+func (t *Transforms) WatermarkLayout(scale float64, margin int, tile bool) error {
+	if t.watermark.image == "" {
+		return errors.New("watermark is not set")
+	}
+
+	if scale < 0 || scale > 1 {
+		return errors.New("watermark scale must be between 0 and 1")
+	}
+
+	if margin < 0 {
+		return errors.New("watermark margin cannot be negative")
+	}
+
+	t.watermark.scale = scale
+	t.watermark.margin = margin
+	t.watermark.tile = tile
+	t.transHash.write(171201, math.Float64bits(scale), uint64(margin))
+	if tile {
+		t.transHash.write(171202)
+	}
+
+	return nil
+}
+
+// Text draw text on image
+// position has the same format as for watermark, empty font, size, color and dpi use default values
+func (t *Transforms) Text(value, font string, size int, color, position string, opacity float32, dpi int) error {
+	if value == "" || position == "" {
+		return errors.New("missing required params text or position")
+	}
+
+	if opacity < 0 || opacity > 1 {
+		return errors.New("opacity must be between 0 and 1")
+	}
+
+	if size < 0 || dpi < 0 {
+		return errors.New("text size and dpi cannot be negative")
+	}
+
+	p := strings.Split(position, "-")
+	if len(p) != 2 {
+		return errors.New("invalid position given")
+	}
+
+	if _, ok := watermarkPosY[p[0]]; !ok {
+		return errors.New("invalid first position argument")
+	}
+
+	if _, ok := watermarkPosX[p[1]]; !ok {
+		return errors.New("invalid second position argument")
+	}
+
+	if color == "" {
+		color = "black"
+	}
+
+	c, err := parseColor(color)
+	if err != nil {
+		return err
+	}
+
+	if font == "" {
+		font = defaultTextFont
+	}
+
+	if size == 0 {
+		size = defaultTextSize
+	}
+
+	if dpi == 0 {
+		dpi = defaultTextDPI
+	}
+
+	t.text = text{text: value, font: font, size: size, dpi: dpi, color: c, xPos: p[1], yPos: p[0], opacity: opacity}
+	t.NotEmpty = true
+	t.transHash.write(171300, murmur3.Sum64([]byte(value)), murmur3.Sum64([]byte(font)), uint64(size), uint64(dpi),
+		uint64(c.R), uint64(c.G), uint64(c.B), murmur3.Sum64([]byte(position)), uint64(opacity*100))
+	return nil
+}
+
+// TextOverlay returns text which should be drawn on image
+func (t *Transforms) TextOverlay() (TextOverlay, bool) {
+	if t.text.text == "" {
+		return TextOverlay{}, false
+	}
+
+	return TextOverlay{
+		Text:    t.text.text,
+		Font:    t.text.font + " " + strconv.Itoa(t.text.size),
+		DPI:     t.text.dpi,
+		Color:   t.text.color,
+		XAlign:  textAlign[t.text.xPos],
+		YAlign:  textAlign[t.text.yPos],
+		Opacity: float64(t.text.opacity),
+	}, true
+}
+
+// Sharpen sharpen image, flat and jagged control amount of sharpening of flat and jagged areas
+func (t *Transforms) Sharpen(sigma, flat, jagged float64) error {
+	if sigma <= 0 || sigma > 10 {
+		return errors.New("sharpen sigma must be between 0 and 10")
+	}
+
+	if flat < 0 || jagged < 0 {
+		return errors.New("sharpen flat and jagged cannot be negative")
+	}
+
+	if jagged == 0 {
+		jagged = defaultSharpenJagged
+	}
+
+	t.sharpen = sharpen{sigma: sigma, flat: flat, jagged: jagged}
+	t.NotEmpty = true
+	t.transHash.write(1218, math.Float64bits(sigma), math.Float64bits(flat), math.Float64bits(jagged))
+	return nil
+}
+
+// Modulate change brightness, contrast and saturation of image (multipliers, 0 and 1 mean no change) and rotate hue by given degrees
+func (t *Transforms) Modulate(brightness, contrast, saturation, hue float64) error {
+	if brightness < 0 || contrast < 0 || saturation < 0 {
+		return errors.New("brightness, contrast and saturation cannot be negative")
+	}
+
+	if brightness > 10 || contrast > 10 || saturation > 10 {
+		return errors.New("brightness, contrast and saturation maximum allowed value is 10")
+	}
+
+	if hue < -360 || hue > 360 {
+		return errors.New("hue must be between -360 and 360")
+	}
+
+	m := modulate{brightness: brightness, contrast: contrast, saturation: saturation, hue: hue, set: true}
+	for _, v := range []*float64{&m.brightness, &m.contrast, &m.saturation} {
+		if *v == 0 {
+			*v = 1
+		}
+	}
+
+	t.modulate = m
+	t.NotEmpty = true
+	t.transHash.write(1219, math.Float64bits(m.brightness), math.Float64bits(m.contrast), math.Float64bits(m.saturation), math.Float64bits(hue))
+	return nil
+}
+
+// Gamma apply gamma correction with given exponent
+func (t *Transforms) Gamma(exponent float64) error {
+	if exponent <= 0 || exponent > 10 {
+		return errors.New("gamma must be between 0 and 10")
+	}
+
+	t.gamma = exponent
+	t.NotEmpty = true
+	t.transHash.write(1220, math.Float64bits(exponent))
+	return nil
+}
+
+// Tint convert image to duotone, highlights are mapped to color and shadows to shadow color (default black)
+func (t *Transforms) Tint(color, shadow string) error {
+	c, err := parseColor(color)
+	if err != nil {
+		return err
+	}
+
+	var s bimg.Color
+	if shadow != "" {
+		if s, err = parseColor(shadow); err != nil {
+			return err
+		}
+	}
+
+	t.tint = tint{color: c, shadow: s, set: true}
+	t.NotEmpty = true
+	t.transHash.write(1221, uint64(c.R), uint64(c.G), uint64(c.B), uint64(s.R), uint64(s.G), uint64(s.B))
+	return nil
+}
+
+// Adjustments returns tonal operations which should be applied on image
+func (t *Transforms) Adjustments() (Adjustments, bool) {
+	if t.sharpen.sigma == 0 && !t.modulate.set && !t.tint.set {
+		return Adjustments{}, false
+	}
+
+	return Adjustments{
+		SharpenSigma:  t.sharpen.sigma,
+		SharpenFlat:   t.sharpen.flat,
+		SharpenJagged: t.sharpen.jagged,
+		Modulate:      t.modulate.set,
+		Brightness:    t.modulate.brightness,
+		Contrast:      t.modulate.contrast,
+		Saturation:    t.modulate.saturation,
+		Hue:           t.modulate.hue,
+		Tint:          t.tint.set,
+		TintColor:     t.tint.color,
+		TintShadow:    t.tint.shadow,
+	}, true
+}
+
+// Mask remove parts of image outside of shape, shape can be "circle", "rounded" (corners with given radius) or "image"
+// image is url of mask, its alpha channel or luminance is used, when shape is empty and image is given image mask is used
+func (t *Transforms) Mask(shape string, radius int, image string) error {
+	if shape == "" && image != "" {
+		shape = "image"
+	}
+
+	switch shape {
+	case "circle":
+	case "rounded":
+		if radius <= 0 {
+			return errors.New("mask radius must be positive")
+		}
+	case "image":
+		if image == "" {
+			return errors.New("missing mask image")
+		}
+	default:
+		return errors.New("unknown mask shape " + shape)
 	}
 
+	t.mask = mask{shape: shape, radius: radius, image: image}
+	t.NotEmpty = true
+	t.transHash.write(1229, murmur3.Sum64([]byte(shape)), uint64(radius), murmur3.Sum64([]byte(image)))
 	return nil
 }
 
-// Crop extract part of image
-func (t *Transforms) ResizeCropAuto(width, height int) error {
-	// Validate width and height are non-negative
-	if width < 0 || height < 0 {
-		return errors.New("width and height cannot be negative")
+// MaskOptions returns mask which should be applied on image
+func (t *Transforms) MaskOptions() (MaskOptions, bool, error) {
+	if t.mask.shape == "" {
+		return MaskOptions{}, false, nil
+	}
+
+	m := MaskOptions{
+		Shape:  t.mask.shape,
+		Radius: t.mask.radius,
+	}
+
+	if t.mask.image != "" {
+		buf, err := fetchImage(t.mask.image)
+		if err != nil {
+			return m, true, err
+		}
+		m.Image = buf
 	}
 
+	return m, true, nil
+}
+
+// Flip mirror image vertically (upside down)
+func (t *Transforms) Flip() {
+	t.flip = true
 	t.NotEmpty = true
-	t.autoCropWidth = width
-	t.autoCropHeight = height
-	t.NoMerge = true
+	t.transHash.write(32311)
+}
 
-	t.transHash.write(31229, uint64(width)*2, uint64(height))
-	return nil
+// Flop mirror image horizontally (left to right)
+func (t *Transforms) Flop() {
+	t.flop = true
+	t.NotEmpty = true
+	t.transHash.write(32321)
 }
 
-// Interlace enable image interlace
-func (t *Transforms) Interlace() error {
-	t.interlace = true
+// Trim remove borders which color differs from background (default white) less than threshold
+func (t *Transforms) Trim(threshold float64, background string) error {
+	if threshold < 0 || threshold > 255 {
+		return errors.New("trim threshold must be between 0 and 255")
+	}
+
+	if threshold == 0 {
+		threshold = defaultTrimThreshold
+	}
+
+	c := bimg.Color{R: 255, G: 255, B: 255}
+	if background != "" {
+		var err error
+		if c, err = parseColor(background); err != nil {
+			return err
+		}
+	}
+
+	t.trim = true
+	t.trimThreshold = threshold
+	t.trimBackground = c
 	t.NotEmpty = true
-	t.transHash.write(1311, 71)
+	t.transHash.write(1222, math.Float64bits(threshold), uint64(c.R), uint64(c.G), uint64(c.B))
 	return nil
 }
 
-// Quality change image quality
-func (t *Transforms) Quality(quality int) error {
-	// Validate quality is in range [1, 100]
-	if quality < 1 || quality > 100 {
-		return errors.New("quality must be between 1 and 100")
+// Zoom enlarge image by integer factor by repeating pixels
+func (t *Transforms) Zoom(factor int) error {
+	if factor < 1 || factor > maxZoom {
+		return errors.New("zoom must be between 1 and " + strconv.Itoa(maxZoom))
 	}
-	t.quality = quality
+
+	t.zoom = factor
 	t.NotEmpty = true
-	t.transHash.write(1401, uint64(t.quality))
+	t.transHash.write(1223, uint64(factor))
 	return nil
 }
 
-// StripMetadata remove EXIF from image
-func (t *Transforms) StripMetadata() error {
-	t.stripMetadata = true
+// Frame use single frame (counted from 0) of animated or multi-page image
+func (t *Transforms) Frame(n int) error {
+	if n < 0 {
+		return errors.New("frame cannot be negative")
+	}
+
+	t.frame = n
+	t.frameSet = true
 	t.NotEmpty = true
-	t.transHash.write(1999)
+	t.transHash.write(1224, uint64(n))
 	return nil
 }
 
-// Blur blur whole image
-func (t *Transforms) Blur(sigma, minAmpl float64) error {
-	// Validate sigma is positive and not too large
-	if sigma <= 0 {
-		return errors.New("sigma must be positive")
-	}
-	if sigma > 100 {
-		return errors.New("sigma value too large, maximum allowed is 100")
+// FrameIndex returns frame which should be extracted from image
+func (t *Transforms) FrameIndex() (int, bool) {
+	return t.frame, t.frameSet
+}
+
+// DPI set resolution used for rasterizing documents (e.g. PDF), libvips default is 72
+func (t *Transforms) DPI(dpi int) error {
+	if dpi <= 0 || dpi > maxDPI {
+		return errors.New("dpi must be between 1 and " + strconv.Itoa(maxDPI))
 	}
+
+	t.dpi = dpi
 	t.NotEmpty = true
-	t.blur.sigma = sigma
-	t.blur.minAmpl = minAmpl
-	t.transHash.write(19121, uint64(t.blur.sigma*1000), uint64(t.blur.minAmpl*1000))
+	t.transHash.write(1226, uint64(dpi))
 	return nil
 }
 
-// Hash return unique transform identifier
-func (t *Transforms) Hash() hash.Hash64 {
-	hashValue := murmur3.New64WithSeed(20171108)
-	transHashB := make([]byte, 8)
-	binary.LittleEndian.PutUint64(transHashB, t.transHash.value())
-	hashValue.Write(transHashB)
-	return hashValue
+// DocumentDPI returns resolution used for rasterizing documents, 0 means default
+func (t *Transforms) DocumentDPI() int {
+	return t.dpi
 }
 
-func (t *Transforms) HashStr() string {
-	return strconv.FormatUint(uint64(t.Hash().Sum64()), 16)
+// MaxFrames set maximal number of frames of animated image, following frames are dropped
+func (t *Transforms) MaxFrames(n int) error {
+	if n <= 0 {
+		return errors.New("max frames must be positive")
+	}
+
+	t.maxFrames = n
+	t.transHash.write(1225, uint64(n))
+	return nil
 }
 
-// Format change image format
-func (t *Transforms) Format(format string) error {
-	t.NotEmpty = true
-	f, err := imageFormat(format)
-	if err != nil {
-		return err
+// MaxRasterArea set maximal width * height of rasterized document page or extracted frame
+func (t *Transforms) MaxRasterArea(n int) error {
+	if n <= 0 {
+		return errors.New("max raster area must be positive")
 	}
-	t.format = f
-	t.FormatStr = format
-	t.transHash.write(1122121, uint64(f))
+
+	t.maxRasterArea = n
+	t.transHash.write(1238, uint64(n))
 	return nil
 }
 
-// Watermark merge two image in one
-func (t *Transforms) Watermark(image string, position string, opacity float32) error {
-	if image == "" || position == "" {
-		return errors.New("missing required params image or position")
+// RasterArea returns maximal width * height of rasterized document page or extracted frame
+func (t *Transforms) RasterArea() int {
+	if t.maxRasterArea == 0 {
+		return DefaultMaxRasterArea
 	}
 
-	// Validate opacity is in range [0, 1]
-	if opacity < 0 || opacity > 1 {
-		return errors.New("opacity must be between 0 and 1")
+	return t.maxRasterArea
+}
+
+// Animation returns options of animated processing of image in given format
+// animation is not preserved when transforms contain operation which can't be applied on each frame
+func (t *Transforms) Animation(format string) (Animation, bool) {
+	if t.frameSet || t.embed || t.fill {
+		return Animation{}, false
 	}
 
-	p := strings.Split(position, "-")
-	if len(p) != 2 {
-		return errors.New("invalid position given")
+	if t.crop && t.gravity != bimg.GravityCentre && t.gravity != bimg.GravitySmart {
+		return Animation{}, false
 	}
 
-	if _, ok := watermarkPosY[p[0]]; !ok {
-		return errors.New("invalid first position argument")
+	for _, op := range t.Summary().Operations {
+		if !animatedOperations[op] {
+			return Animation{}, false
+		}
 	}
 
-	if _, ok := watermarkPosX[p[1]]; !ok {
-		return errors.New("invalid second position argument")
+	if t.FormatStr != "" {
+		format = t.FormatStr
 	}
 
-	if image == "" {
-		return errors.New("empty image")
+	if !animatedFormats[format] {
+		return Animation{}, false
+	}
+
+	maxFrames := t.maxFrames
+	if maxFrames == 0 {
+		maxFrames = DefaultMaxFrames
 	}
 
+	return Animation{
+		Width:     t.width,
+		Height:    t.height,
+		Crop:      t.crop,
+		Enlarge:   t.enlarge,
+		Force:     !t.crop && !t.preserveAspectRatio && t.width != 0 && t.height != 0,
+		MaxFrames: maxFrames,
+		Format:    format,
+		Quality:   t.outputQuality(format),
+	}, true
+}
+
+// Info return image metadata as JSON instead of image, other operations of transform are ignored
+func (t *Transforms) Info() {
+	t.info = true
 	t.NotEmpty = true
-	t.transHash.write(171200, uint64(len(image)), uint64(len(position)), uint64(opacity*100))
-	t.watermark = watermark{image: image, xPos: p[1], yPos: p[0], opacity: opacity}
+	// metadata has to describe result of previous transforms
+	t.NoMerge = true
+	t.transHash.write(1227)
+}
+
+// HasInfo returns true when metadata should be returned instead of image
+func (t *Transforms) HasInfo() bool {
+	return t.info
+}
+
+// Palette return dominant color and palette of image as JSON instead of image, other operations of transform are ignored
+// zero colors means default palette size
+func (t *Transforms) Palette(colors int) error {
+	if colors == 0 {
+		colors = defaultPaletteColors
+	}
+
+	if colors < 1 || colors > maxPaletteColors {
+		return errors.New("palette colors must be between 1 and " + strconv.Itoa(maxPaletteColors))
+	}
+
+	t.paletteColors = colors
+	t.NotEmpty = true
+	// palette has to describe result of previous transforms
+	t.NoMerge = true
+	t.transHash.write(1228, uint64(colors))
 	return nil
 }
 
+// PaletteColors returns number of colors in palette and true when palette should be returned instead of image
+func (t *Transforms) PaletteColors() (int, bool) {
+	return t.paletteColors, t.paletteColors != 0
+}
+
 // Grayscale convert image to B&W
 func (t *Transforms) Grayscale() {
 	t.interpretation = bimg.InterpretationBW
@@ -404,6 +1887,13 @@ func (t *Transforms) Merge(other Transforms) error {
 		t.watermark = other.watermark
 	}
 
+	if other.text.text != "" {
+		if t.text.text != "" {
+			return errors.New("already have text")
+		}
+		t.text = other.text
+	}
+
 	if other.width != 0 {
 		t.width = other.width
 	}
@@ -438,6 +1928,38 @@ func (t *Transforms) Merge(other Transforms) error {
 		t.gravity = other.gravity
 	}
 
+	if other.background != nil {
+		t.background = other.background
+	}
+
+	if other.focal.set {
+		t.focal = other.focal
+	}
+
+	if other.extend.width != 0 {
+		t.extend = other.extend
+	}
+
+	if other.mask.shape != "" {
+		t.mask = other.mask
+	}
+
+	if other.sharpen.sigma != 0 {
+		t.sharpen = other.sharpen
+	}
+
+	if other.modulate.set {
+		t.modulate = other.modulate
+	}
+
+	if other.gamma != 0 {
+		t.gamma = other.gamma
+	}
+
+	if other.tint.set {
+		t.tint = other.tint
+	}
+
 	if other.blur.minAmpl != 0 {
 		t.blur.minAmpl = t.blur.minAmpl + other.blur.minAmpl
 	}
@@ -450,10 +1972,53 @@ func (t *Transforms) Merge(other Transforms) error {
 		t.interlace = other.interlace
 	}
 
+	if other.flip {
+		t.flip = other.flip
+	}
+
+	if other.flop {
+		t.flop = other.flop
+	}
+
+	if other.trim {
+		t.trim = other.trim
+		t.trimThreshold = other.trimThreshold
+		t.trimBackground = other.trimBackground
+	}
+
+	if other.zoom != 0 {
+		t.zoom = other.zoom
+	}
+
+	if other.frameSet {
+		t.frame = other.frame
+		t.frameSet = true
+	}
+
+	if other.dpi != 0 {
+		t.dpi = other.dpi
+	}
+
+	if other.maxFrames != 0 {
+		t.maxFrames = other.maxFrames
+	}
+
+	if other.maxRasterArea != 0 {
+		t.maxRasterArea = other.maxRasterArea
+	}
+
 	if other.quality != 0 {
 		t.quality = other.quality
 	}
 
+	if other.autoQuality != "" {
+		t.autoQuality = other.autoQuality
+	}
+
+	if other.autoFormat {
+		t.autoFormat = other.autoFormat
+	}
+
 	if other.format != 0 {
 		t.format = other.format
 		t.FormatStr = other.FormatStr
@@ -463,6 +2028,55 @@ func (t *Transforms) Merge(other Transforms) error {
 		t.stripMetadata = other.stripMetadata
 	}
 
+	if other.metadata.policy != "" {
+		t.metadata = other.metadata
+	}
+
+	if other.colorProfile != "" {
+		t.colorProfile = other.colorProfile
+	}
+
+	if other.noProfile {
+		t.noProfile = other.noProfile
+	}
+
+	if other.encoder.speedSet {
+		t.encoder.speed = other.encoder.speed
+		t.encoder.speedSet = true
+	}
+
+	if other.encoder.effort != 0 {
+		t.encoder.effort = other.encoder.effort
+	}
+
+	if other.encoder.lossless {
+		t.encoder.lossless = other.encoder.lossless
+	}
+
+	if other.encoder.progressive || other.encoder.subsample != "" || other.encoder.trellis || other.encoder.quantTable != 0 {
+		t.encoder.progressive = other.encoder.progressive
+		t.encoder.subsample = other.encoder.subsample
+		t.encoder.trellis = other.encoder.trellis
+		t.encoder.quantTable = other.encoder.quantTable
+	}
+
+	if other.encoder.palette || other.compression != 0 {
+		t.encoder.palette = other.encoder.palette
+		t.encoder.colors = other.encoder.colors
+		t.compression = other.compression
+	}
+
+	if other.encoder.maxBytes != 0 {
+		t.encoder.maxBytes = other.encoder.maxBytes
+		t.encoder.downscale = other.encoder.downscale
+	}
+
+	if other.encoder.nearLossless || other.encoder.webpEffort != 0 || other.encoder.smartSubsample {
+		t.encoder.nearLossless = other.encoder.nearLossless
+		t.encoder.webpEffort = other.encoder.webpEffort
+		t.encoder.smartSubsample = other.encoder.smartSubsample
+	}
+
 	t.transHash.write(other.transHash.value())
 	t.NotEmpty = other.NotEmpty
 
@@ -500,6 +2114,49 @@ func Merge(transformsTab []Transforms) []Transforms {
 	return result
 }
 
+func parseColor(color string) (bimg.Color, error) {
+	if c, ok := namedColors[strings.ToLower(color)]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) != 6 {
+		return bimg.Color{}, errors.New("invalid color " + color)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return bimg.Color{}, errors.New("invalid color " + color)
+	}
+
+	return bimg.Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// parseColorAlpha parse color with optional opacity given as "transparent" or "#rrggbbaa"
+func parseColorAlpha(color string) (bimg.Color, uint8, error) {
+	if strings.ToLower(color) == "transparent" {
+		return bimg.Color{}, 0, nil
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 8 {
+		alpha, err := strconv.ParseUint(hex[6:], 16, 8)
+		if err != nil {
+			return bimg.Color{}, 0, errors.New("invalid color " + color)
+		}
+
+		c, err := parseColor(hex[:6])
+		return c, uint8(alpha), err
+	}
+
+	c, err := parseColor(color)
+	return c, 255, err
+}
+
 func imageFormat(format string) (bimg.ImageType, error) {
 	switch format {
 	case "jpeg", "jpg":
@@ -514,12 +2171,22 @@ func imageFormat(format string) (bimg.ImageType, error) {
 		return bimg.SVG, nil
 	case "pdf":
 		return bimg.PDF, nil
+	case "avif":
+		return bimg.AVIF, nil
+	case "jxl":
+		return JXL, nil
+	case "blurhash":
+		return BlurHash, nil
+	case "lqip":
+		return LQIP, nil
 	default:
 		return bimg.UNKNOWN, errors.New("Unknown format " + format)
 	}
 }
 
 func (t *Transforms) calculateAutoCrop(info ImageInfo) (int, int, int, int) {
+	fx := t.focal.relative(t.focal.x, info.width)
+	fy := t.focal.relative(t.focal.y, info.height)
 	if t.width != 0 {
 		info.width = t.width
 	}
@@ -546,19 +2213,77 @@ func (t *Transforms) calculateAutoCrop(info ImageInfo) (int, int, int, int) {
 		cropHeight = float64(info.height)
 	}
 
+	if t.focal.set {
+		return focalOffset(fx, int(cropWidth), info.width), focalOffset(fy, int(cropHeight), info.height), int(cropWidth), int(cropHeight)
+	}
+
 	cropX := math.Floor((float64(info.width) - cropWidth) / 2.)
 	cropY := math.Floor((float64(info.height) - cropHeight) / 5.)
 
 	return int(cropX), int(cropY), int(cropWidth), int(cropHeight)
 }
 
+// relative returns focal point coordinate as fraction of image size
+func (f focalPoint) relative(value float64, size int) float64 {
+	if value <= 1 || size == 0 {
+		return value
+	}
+
+	return math.Min(value/float64(size), 1)
+}
+
+// focalCropArea returns area of image which has aspect ratio of crop and is centered on focal point
+func (t *Transforms) focalCropArea(info ImageInfo) bimg.Options {
+	width, height := info.width, info.height
+	// EXIF orientations 5-8 swap image dimensions after auto rotation
+	if info.orientation >= 5 {
+		width, height = height, width
+	}
+
+	scale := math.Max(float64(t.width)/float64(width), float64(t.height)/float64(height))
+	areaWidth := int(math.Min(float64(width), math.Round(float64(t.width)/scale)))
+	areaHeight := int(math.Min(float64(height), math.Round(float64(t.height)/scale)))
+
+	return bimg.Options{
+		Left:       focalOffset(t.focal.relative(t.focal.x, width), areaWidth, width),
+		Top:        focalOffset(t.focal.relative(t.focal.y, height), areaHeight, height),
+		AreaWidth:  areaWidth,
+		AreaHeight: areaHeight,
+	}
+}
+
+// focalOffset returns offset of window centered on focal point and clamped to image bounds
+func focalOffset(focal float64, window, size int) int {
+	offset := int(math.Round(focal*float64(size) - float64(window)/2))
+	if offset > size-window {
+		offset = size - window
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	return offset
+}
+
 // BimgOptions return complete options for bimg lib
 func (t *Transforms) BimgOptions(imageInfo ImageInfo) ([]bimg.Options, error) {
 	var opts []bimg.Options
+	// borders are trimmed before any other operation, so following steps see trimmed image
+	if t.trim {
+		opts = append(opts, bimg.Options{Trim: true, Threshold: t.trimThreshold, Background: t.trimBackground})
+	}
+
+	// crop window is moved to focal point before image is resized, so later crop only scales image
+	if t.focal.set && t.crop && t.width > 0 && t.height > 0 && t.areaWidth == 0 && imageInfo.width > 0 && imageInfo.height > 0 {
+		opts = append(opts, t.focalCropArea(imageInfo))
+	}
+
 	if t.fill && t.width > 0 && t.height > 0 {
 		ar := float64(t.width) / float64(t.height)
 		b := bimg.Options{
-			Crop: true,
+			Crop:    true,
+			Gravity: t.gravity,
 		}
 		if ar > 1 {
 			b.Width = imageInfo.width
@@ -592,21 +2317,78 @@ func (t *Transforms) BimgOptions(imageInfo ImageInfo) ([]bimg.Options, error) {
 		Crop:          t.crop,
 		Embed:         t.embed,
 		Interlace:     t.interlace,
-		Quality:       t.quality,
 		StripMetadata: t.stripMetadata,
 		GaussianBlur: bimg.GaussianBlur{
 			Sigma:   t.blur.sigma,
 			MinAmpl: t.blur.minAmpl,
 		},
-		Rotate: t.rotate,
+		Rotate:    t.rotate,
+		Flip:      t.flip,
+		Flop:      t.flop,
+		Gamma:     t.gamma,
+		Lossless:  t.encoder.lossless,
+		NoProfile: t.noProfile,
 	}
 
 	if t.gravity != 0 {
 		b.Gravity = t.gravity
 	}
 
+	if t.background != nil {
+		b.Background = *t.background
+	}
+
 	if t.FormatStr != "" {
 		b.Type = t.format
+		b.Quality = t.outputQuality(t.FormatStr)
+	} else {
+		b.Quality = t.outputQuality(imageInfo.format)
+		// extended image and extracted frame are passed to bimg as lossless intermediate image, so source format has to be restored
+		// documents can't be saved in source format, so they are returned as intermediate image
+		if format, err := imageFormat(imageInfo.format); err == nil && format != bimg.PDF && format != bimg.SVG && (t.extend.width != 0 || t.frameSet || imageInfo.intermediate) {
+			b.Type = format
+		}
+	}
+
+	// masked image needs alpha channel, formats without it are replaced by png
+	if t.mask.shape != "" {
+		format := b.Type
+		if format == bimg.UNKNOWN {
+			format, _ = imageFormat(imageInfo.format)
+		}
+
+		if !alphaFormats[format] {
+			b.Type = bimg.PNG
+		}
+	}
+
+	output := t.outputFormat(imageInfo.format)
+	switch output {
+	case "jpeg":
+		b.Interlace = b.Interlace || t.encoder.progressive
+	case "png":
+		b.Palette = t.encoder.palette
+		b.Compression = t.compression
+	}
+
+	// bimg produce lossless intermediate image when encoder options are not supported by it or image is changed by engine,
+	// final encoding is done by engine
+	if t.customEncoder(output) || t.postProcessed() {
+		b.Type = bimg.PNG
+		b.Palette = false
+		b.Lossless = false
+	}
+
+	switch b.Type {
+	case bimg.AVIF:
+		b.Speed = defaultAvifSpeed
+		if t.encoder.speedSet {
+			b.Speed = t.encoder.speed
+		}
+	case JXL, BlurHash, LQIP:
+		// bimg produce lossless intermediate image, final encoding is done by engine
+		b.Type = bimg.PNG
+		b.Lossless = false
 	}
 
 	if t.interpretation != 0 {
@@ -614,12 +2396,6 @@ func (t *Transforms) BimgOptions(imageInfo ImageInfo) ([]bimg.Options, error) {
 	}
 
 	if t.watermark.image != "" {
-		// fetch image
-		buf, err := t.watermark.fetchImage()
-		if err != nil {
-			return opts, err
-		}
-
 		// calculate correct image dimensions
 		width := imageInfo.width
 		height := imageInfo.height
@@ -635,7 +2411,10 @@ func (t *Transforms) BimgOptions(imageInfo ImageInfo) ([]bimg.Options, error) {
 			width = t.height * width / imageInfo.height
 		}
 
-		top, left := t.watermark.calculatePostion(width, height)
+		buf, top, left, err := t.watermark.prepareImage(width, height)
+		if err != nil {
+			return opts, err
+		}
 
 		b.WatermarkImage = bimg.WatermarkImage{
 			Left:    left,
@@ -663,9 +2442,22 @@ func (t *Transforms) BimgOptions(imageInfo ImageInfo) ([]bimg.Options, error) {
 		}
 	}
 
+	// zoom is applied on output of other operations, because bimg zooms image before resize
+	if t.zoom > 1 {
+		opts = append(opts, bimg.Options{Zoom: t.zoom - 1, Type: b.Type, Quality: b.Quality, Interlace: b.Interlace,
+			StripMetadata: b.StripMetadata, Lossless: b.Lossless, NoProfile: b.NoProfile, Palette: b.Palette, Compression: b.Compression})
+	}
+
 	return opts, nil
 }
 
+func boolToUint64(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // FNV  for uint64
 type fnvI64 uint64
 